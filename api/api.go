@@ -1,29 +1,48 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 
 	"github.com/topoface/snippet-challenge/app"
 	"github.com/topoface/snippet-challenge/model"
 	"github.com/topoface/snippet-challenge/services/configservice"
+	"github.com/topoface/snippet-challenge/utils"
 	"github.com/topoface/snippet-challenge/web"
 )
 
 // Routes : define api routes
 type Routes struct {
-	Root    *mux.Router // ''
-	APIRoot *mux.Router // ''
+	Root      *mux.Router // ''
+	APIRoot   *RouteGroup // 'api/v1', and '' as a deprecated alias
+	APIRootV2 *RouteGroup // 'api/v2'
 
-	Snippets *mux.Router // 'spaces'
+	Snippets   *RouteGroup // 'api/v1/snippets'
+	SnippetsV2 *RouteGroup // 'api/v2/snippets'
+
+	Config *RouteGroup // 'api/v1/config'
+
+	Audits *RouteGroup // 'api/v1/audits'
 }
 
+// The API used to be served at the root, which is kept as an alias of /api/v1 until the sunset.
+var (
+	rootAliasDeprecation = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	rootAliasSunset      = time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC)
+)
+
 // API structure
 type API struct {
 	ConfigService       configservice.ConfigService
 	GetGlobalAppOptions app.AppOptionCreator
 	BaseRoutes          *Routes
+
+	rootAlias *mux.Route
+	openAPI   []byte
 }
 
 // Init : init api routes
@@ -35,17 +54,62 @@ func Init(configservice configservice.ConfigService, globalOptionsFunc app.AppOp
 	}
 
 	api.BaseRoutes.Root = root
-	api.BaseRoutes.APIRoot = root.PathPrefix(model.API_URL_SUFFIX).Subrouter()
 
-	api.BaseRoutes.Snippets = api.BaseRoutes.APIRoot.PathPrefix("/snippets").Subrouter()
+	api.rootAlias = root.PathPrefix("")
+	rootAlias := api.rootAlias.Subrouter()
+	rootAlias.Use(deprecatedAlias(configservice, model.API_URL_SUFFIX_V1, rootAliasDeprecation, rootAliasSunset))
+	api.BaseRoutes.APIRoot = NewRouteGroup(root.PathPrefix(model.API_URL_SUFFIX_V1).Subrouter(), rootAlias)
+	api.BaseRoutes.APIRootV2 = NewRouteGroup(root.PathPrefix(model.API_URL_SUFFIX_V2).Subrouter())
+
+	api.BaseRoutes.Snippets = api.BaseRoutes.APIRoot.PathPrefix("/snippets")
+	api.BaseRoutes.SnippetsV2 = api.BaseRoutes.APIRootV2.PathPrefix("/snippets")
+
+	api.BaseRoutes.Config = api.BaseRoutes.APIRoot.PathPrefix("/config")
+
+	api.BaseRoutes.Audits = api.BaseRoutes.APIRoot.PathPrefix("/audits")
 
 	api.InitSnippets()
+	api.InitSystem()
+	api.InitConfig()
+	api.InitAudit()
+	api.InitOpenAPI()
+
+	api.openAPI = marshalOpenAPI(api.buildOpenAPI())
 
 	// root.Handle("/api/{anything:.*}", http.HandlerFunc(api.Handle404))
 
 	return api
 }
 
+// deprecatedAlias marks responses of routes served under a deprecated alias with the Deprecation
+// and Sunset headers, and links to the same route under the successor prefix.
+func deprecatedAlias(configService configservice.ConfigService, successorPrefix string, deprecation, sunset time.Time) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(model.HEADER_DEPRECATION, fmt.Sprintf("@%d", deprecation.Unix()))
+			w.Header().Set(model.HEADER_SUNSET, sunset.Format(http.TimeFormat))
+			w.Header().Set(model.HEADER_LINK, fmt.Sprintf("<%s>; rel=\"successor-version\"", successorPath(configService.Config(), successorPrefix, r.URL.Path)))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// successorPath returns the path of the requested route under the successor prefix. The successor
+// prefix goes after the subpath of the SiteURL, which the request path may or may not include
+// depending on whether a proxy in front of the server strips it.
+func successorPath(cfg *model.Config, successorPrefix, requestPath string) string {
+	subpath, err := utils.GetSubpathFromConfig(cfg)
+	if err != nil || subpath == "/" {
+		return successorPrefix + requestPath
+	}
+
+	if requestPath == subpath || strings.HasPrefix(requestPath, subpath+"/") {
+		requestPath = requestPath[len(subpath):]
+	}
+
+	return subpath + successorPrefix + requestPath
+}
+
 // Handle404 : handle requests to undefined endpoints
 func (api *API) Handle404(w http.ResponseWriter, r *http.Request) {
 	// web.Handle404(api.ConfigService, w, r)
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+func TestSuccessorPath(t *testing.T) {
+	for name, tc := range map[string]struct {
+		SiteURL     string
+		RequestPath string
+		Expected    string
+	}{
+		"no site url":             {"", "/snippets/recipe", "/api/v1/snippets/recipe"},
+		"site url without path":   {"https://example.com", "/snippets/recipe", "/api/v1/snippets/recipe"},
+		"site url with root path": {"https://example.com/", "/snippets/recipe", "/api/v1/snippets/recipe"},
+		"subpath in request":      {"https://example.com/paste", "/paste/snippets/recipe", "/paste/api/v1/snippets/recipe"},
+		"subpath stripped":        {"https://example.com/paste", "/snippets/recipe", "/paste/api/v1/snippets/recipe"},
+		"trailing slash":          {"https://example.com/paste/", "/paste/snippets/recipe", "/paste/api/v1/snippets/recipe"},
+		"nested subpath":          {"https://example.com/a/b", "/a/b/config", "/a/b/api/v1/config"},
+		"subpath as name prefix":  {"https://example.com/paste", "/pastebin/recipe", "/paste/api/v1/pastebin/recipe"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := &model.Config{}
+			cfg.SetDefaults()
+			*cfg.ServiceSettings.SiteURL = tc.SiteURL
+
+			assert.Equal(t, tc.Expected, successorPath(cfg, model.API_URL_SUFFIX_V1, tc.RequestPath))
+		})
+	}
+}
+
+func TestRootAlias(t *testing.T) {
+	th := Setup(t, func(cfg *model.Config) {
+		*cfg.ServiceSettings.SiteURL = "https://example.com/paste"
+	})
+
+	resp, body := th.MakeRequest(t, http.MethodPost, "/api/v1/snippets", `{"name":"recipe","expires_in":60,"snippet":"1 cup flour"}`, nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, body)
+
+	assertDeprecated := func(t *testing.T, resp *http.Response, successor string) {
+		t.Helper()
+
+		assert.Equal(t, fmt.Sprintf("@%d", rootAliasDeprecation.Unix()), resp.Header.Get(model.HEADER_DEPRECATION))
+		sunset, err := http.ParseTime(resp.Header.Get(model.HEADER_SUNSET))
+		require.NoError(t, err)
+		assert.True(t, sunset.Equal(rootAliasSunset))
+		assert.Equal(t, "<"+successor+`>; rel="successor-version"`, resp.Header.Get(model.HEADER_LINK))
+	}
+
+	t.Run("root alias", func(t *testing.T) {
+		resp, body := th.MakeRequest(t, http.MethodGet, "/snippets/recipe", "", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		assert.Contains(t, body, `"name":"recipe"`)
+		assertDeprecated(t, resp, "/paste/api/v1/snippets/recipe")
+	})
+
+	t.Run("root alias error", func(t *testing.T) {
+		resp, _ := th.MakeRequest(t, http.MethodGet, "/snippets/missing", "", nil)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assertDeprecated(t, resp, "/paste/api/v1/snippets/missing")
+	})
+
+	for _, path := range []string{"/api/v1/snippets/recipe", "/api/v2/snippets/recipe", "/healthz"} {
+		t.Run(path, func(t *testing.T) {
+			resp, body := th.MakeRequest(t, http.MethodGet, path, "", nil)
+			require.Equal(t, http.StatusOK, resp.StatusCode, body)
+			assert.Empty(t, resp.Header.Get(model.HEADER_DEPRECATION))
+			assert.Empty(t, resp.Header.Get(model.HEADER_SUNSET))
+			assert.Empty(t, resp.Header.Get(model.HEADER_LINK))
+		})
+	}
+}
+
+func TestSnippetsV2(t *testing.T) {
+	th := Setup(t, func(cfg *model.Config) {
+		*cfg.ServiceSettings.SiteURL = "https://example.com"
+	})
+
+	assertShape := func(t *testing.T, body string, expiresIn time.Duration) {
+		t.Helper()
+
+		var snippet map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(body), &snippet))
+		assert.Len(t, snippet, 4, body)
+		assert.Equal(t, "recipe", snippet["name"])
+		assert.Equal(t, "https://example.com/api/v2/snippets/recipe", snippet["url"])
+		assert.Equal(t, "1 cup flour", snippet["content"])
+
+		// The expiry is in milliseconds since the epoch.
+		expiresAt, ok := snippet["expires_at"].(float64)
+		require.True(t, ok, "expires_at should be a number: %v", snippet["expires_at"])
+		remaining := time.Until(time.Unix(0, int64(expiresAt)*int64(time.Millisecond)))
+		assert.InDelta(t, float64(expiresIn), float64(remaining), float64(5*time.Second))
+	}
+
+	resp, body := th.MakeRequest(t, http.MethodPost, "/api/v2/snippets", `{"name":"recipe","expires_in":60,"content":"1 cup flour"}`, nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, body)
+	assertShape(t, body, time.Minute)
+
+	resp, body = th.MakeRequest(t, http.MethodGet, "/api/v2/snippets/recipe", "", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	// Reading a snippet extends its expiry.
+	assertShape(t, body, time.Minute+model.SNIPPET_EXPIRY_EXTENSION)
+
+	t.Run("v1 body field is rejected", func(t *testing.T) {
+		resp, body := th.MakeRequest(t, http.MethodPost, "/api/v2/snippets", `{"name":"other","expires_in":60,"snippet":"1 cup flour"}`, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+		assert.False(t, strings.Contains(body, `"url"`))
+	})
+}
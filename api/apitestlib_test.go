@@ -0,0 +1,126 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/app"
+	"github.com/topoface/snippet-challenge/config"
+	"github.com/topoface/snippet-challenge/mlog"
+	"github.com/topoface/snippet-challenge/model"
+)
+
+const testAdminToken = "test-admin-token"
+
+// TestHelper runs a server listening on a random local port with the API registered by Init.
+type TestHelper struct {
+	Server  *app.Server
+	SiteURL string
+	// DataDir is the temporary directory holding the files of the server, such as the audit log.
+	DataDir string
+	// ConfigPath is the watched config file of a server started by SetupWithFileStore.
+	ConfigPath string
+
+	client *http.Client
+}
+
+// Setup starts a server with the default config, modified by the given functions, and stops it
+// at the end of the test.
+func Setup(t *testing.T, updateConfig ...func(*model.Config)) *TestHelper {
+	return setup(t, false, updateConfig...)
+}
+
+// SetupWithFileStore is like Setup, but the config is read from a file the server watches for
+// changes.
+func SetupWithFileStore(t *testing.T, updateConfig ...func(*model.Config)) *TestHelper {
+	return setup(t, true, updateConfig...)
+}
+
+func setup(t *testing.T, fileStore bool, updateConfig ...func(*model.Config)) *TestHelper {
+	dataDir, err := ioutil.TempDir("", "apitest")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dataDir)
+	})
+
+	cfg := &model.Config{}
+	cfg.SetDefaults()
+	*cfg.FileSettings.Directory = dataDir
+	*cfg.ServiceSettings.ListenAddress = "127.0.0.1:0"
+	*cfg.ServiceSettings.AdminAccessToken = testAdminToken
+	*cfg.ServiceSettings.ShutdownDrainPeriod = 0
+	*cfg.LogSettings.EnableConsole = false
+	*cfg.LogSettings.EnableFile = false
+	*cfg.AuditSettings.FileLocation = dataDir
+	for _, f := range updateConfig {
+		f(cfg)
+	}
+
+	var configStore config.Store
+	var configPath string
+	if fileStore {
+		configPath = filepath.Join(dataDir, "config.json")
+		require.NoError(t, ioutil.WriteFile(configPath, []byte(cfg.ToJSON()), 0600))
+		configStore, err = config.NewFileStore(configPath, true)
+	} else {
+		configStore, err = config.NewMemoryStoreWithOptions(&config.MemoryStoreOptions{InitialConfig: cfg})
+	}
+	require.NoError(t, err)
+
+	server, err := app.NewServer(app.ConfigStore(configStore), app.SetLogger(mlog.NewLogger(&mlog.LoggerConfiguration{})))
+	require.NoError(t, err)
+
+	Init(server, server.AppOptions, server.Router)
+
+	require.NoError(t, server.Start())
+
+	th := &TestHelper{
+		Server:     server,
+		SiteURL:    "http://" + server.ListenAddr.String(),
+		DataDir:    dataDir,
+		ConfigPath: configPath,
+		client:     &http.Client{},
+	}
+
+	t.Cleanup(func() {
+		th.client.CloseIdleConnections()
+		server.Shutdown()
+	})
+
+	return th
+}
+
+// MakeRequest sends a request to the path of the server and returns the response with its body.
+func (th *TestHelper) MakeRequest(t *testing.T, method, path, body string, header http.Header) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, th.SiteURL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := th.client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp, string(respBody)
+}
+
+// MakeAdminRequest sends a request authenticated with the admin access token.
+func (th *TestHelper) MakeAdminRequest(t *testing.T, method, path, body string) (*http.Response, string) {
+	t.Helper()
+
+	return th.MakeRequest(t, method, path, body, http.Header{
+		model.HEADER_AUTH: []string{model.HEADER_BEARER + " " + testAdminToken},
+	})
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+func (api *API) InitAudit() {
+	api.BaseRoutes.Audits.Handle("", api.AdminHandler(getAudits)).Methods("GET")
+}
+
+// getAudits returns the audit records matching the event, actor, target and status query
+// parameters, created between since and until in milliseconds, a page at a time.
+func getAudits(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	query := &model.AuditQuery{
+		Event:  params.Get("event"),
+		Actor:  params.Get("actor"),
+		Target: params.Get("target"),
+		Status: params.Get("status"),
+	}
+
+	for name, dest := range map[string]*int64{"since": &query.Since, "until": &query.Until} {
+		if value := params.Get(name); value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				c.Err = model.NewInvalidUrlParamError(name)
+				return
+			}
+			*dest = parsed
+		}
+	}
+
+	for name, dest := range map[string]*int{"page": &query.Page, "per_page": &query.PerPage} {
+		if value := params.Get(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				c.Err = model.NewInvalidUrlParamError(name)
+				return
+			}
+			*dest = parsed
+		}
+	}
+
+	audits, err := c.App.GetAudits(query)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(model.AuditListToJSON(audits)))
+}
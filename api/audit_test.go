@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+// getAudits returns the audit records matching the query string of /api/v1/audits.
+func (th *TestHelper) getAudits(t *testing.T, query string) []*model.Audit {
+	t.Helper()
+
+	resp, body := th.MakeAdminRequest(t, http.MethodGet, "/api/v1/audits?"+query, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+
+	var audits []*model.Audit
+	require.NoError(t, json.Unmarshal([]byte(body), &audits))
+	return audits
+}
+
+// readAuditLog returns the records appended to the audit log file.
+func (th *TestHelper) readAuditLog(t *testing.T) []map[string]interface{} {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(filepath.Join(th.DataDir, "audit.log"))
+	require.NoError(t, err)
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &record), line)
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestFailedAdminAuthIsAudited(t *testing.T) {
+	th := Setup(t)
+
+	resp, _ := th.MakeRequest(t, http.MethodPatch, "/api/v1/config", `{}`, http.Header{
+		model.HEADER_AUTH: []string{"Bearer wrong-token"},
+	})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	requestID := resp.Header.Get(model.HEADER_REQUEST_ID)
+
+	audits := th.getAudits(t, "event=patchConfig")
+	require.Len(t, audits, 1)
+	assert.Equal(t, model.AUDIT_STATUS_FAIL, audits[0].Status)
+	assert.Equal(t, model.AUDIT_ACTOR_ANONYMOUS, audits[0].Actor)
+	assert.Equal(t, "/api/v1/config", audits[0].Target)
+	assert.Equal(t, requestID, audits[0].RequestID)
+	assert.NotEmpty(t, audits[0].Error)
+
+	var logged []map[string]interface{}
+	for _, record := range th.readAuditLog(t) {
+		if record["event"] == "patchConfig" {
+			logged = append(logged, record)
+		}
+	}
+	require.Len(t, logged, 1)
+	assert.Equal(t, audits[0].ID, logged[0]["id"])
+	assert.Equal(t, model.AUDIT_STATUS_FAIL, logged[0]["status"])
+	assert.Equal(t, requestID, logged[0]["request_id"])
+}
+
+func TestConfigFileChangeIsAudited(t *testing.T) {
+	th := SetupWithFileStore(t)
+
+	cfg := th.Server.Config().Clone()
+	*cfg.ServiceSettings.EnableDeveloper = true
+	require.NoError(t, ioutil.WriteFile(th.ConfigPath, []byte(cfg.ToJSON()), 0600))
+
+	require.Eventually(t, func() bool {
+		return *th.Server.Config().ServiceSettings.EnableDeveloper
+	}, 5*time.Second, 10*time.Millisecond, "the edited config file should be applied")
+
+	audits := th.getAudits(t, "event=configChanged")
+	require.Len(t, audits, 1)
+	assert.Equal(t, model.AUDIT_STATUS_SUCCESS, audits[0].Status)
+	assert.Equal(t, model.AUDIT_ACTOR_SYSTEM, audits[0].Actor)
+	assert.Equal(t, "ServiceSettings.EnableDeveloper", audits[0].Target)
+
+	records := th.readAuditLog(t)
+	require.NotEmpty(t, records)
+	last := records[len(records)-1]
+	assert.Equal(t, "configChanged", last["event"])
+	assert.Equal(t, audits[0].ID, last["id"])
+}
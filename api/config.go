@@ -0,0 +1,115 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+func (api *API) InitConfig() {
+	api.BaseRoutes.Config.Handle("", api.AdminHandler(getConfig)).Methods("GET")
+	api.BaseRoutes.Config.Handle("", api.AdminHandler(patchConfig)).Methods("PATCH")
+	api.BaseRoutes.Config.Handle("/reload", api.AdminHandler(reloadConfig)).Methods("POST")
+	api.BaseRoutes.Config.Handle("/environment", api.AdminHandler(getEnvironmentConfig)).Methods("GET")
+	api.BaseRoutes.Config.Handle("/history", api.AdminHandler(getConfigHistory)).Methods("GET")
+	api.BaseRoutes.Config.Handle("/history/diff", api.AdminHandler(getConfigHistoryDiff)).Methods("GET")
+	api.BaseRoutes.Config.Handle("/history/{id}/rollback", api.AdminHandler(rollbackConfig)).Methods("POST")
+}
+
+func getConfig(c *Context, w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Write([]byte(c.App.GetSanitizedConfig().ToJSON()))
+}
+
+func patchConfig(c *Context, w http.ResponseWriter, r *http.Request) {
+	auditRec := c.MakeAuditRecord("patchConfig")
+	auditRec.Target = "config"
+	defer c.LogAuditRec(auditRec)
+
+	patch, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		c.Err = model.InvalidRequestBodyError()
+		return
+	}
+
+	cfg, appErr := c.App.PatchConfig(patch)
+	if appErr != nil {
+		c.Err = appErr
+		return
+	}
+
+	auditRec.Success()
+	c.Log.Info("Config patched by admin")
+
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Write([]byte(cfg.ToJSON()))
+}
+
+func reloadConfig(c *Context, w http.ResponseWriter, r *http.Request) {
+	auditRec := c.MakeAuditRecord("reloadConfig")
+	auditRec.Target = "config"
+	defer c.LogAuditRec(auditRec)
+
+	if err := c.App.ReloadConfig(); err != nil {
+		c.Err = model.NewAppError("reloadConfig", "api.config.reload_config.app_error", nil, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	auditRec.Success()
+	c.Log.Info("Config reloaded by admin")
+
+	ReturnStatusNoContent(w)
+}
+
+func getEnvironmentConfig(c *Context, w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Write([]byte(model.StringInterfaceToJSON(c.App.GetEnvironmentConfig())))
+}
+
+func getConfigHistory(c *Context, w http.ResponseWriter, r *http.Request) {
+	entries, err := c.App.GetConfigHistory()
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(model.ConfigHistoryEntryListToJSON(entries)))
+}
+
+func getConfigHistoryDiff(c *Context, w http.ResponseWriter, r *http.Request) {
+	from := r.URL.Query().Get("from")
+	if from == "" {
+		c.Err = model.ParamNotFoundError("getConfigHistoryDiff", "from")
+		return
+	}
+
+	diffs, err := c.App.GetConfigHistoryDiff(from, r.URL.Query().Get("to"))
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(diffs.ToJSON()))
+}
+
+func rollbackConfig(c *Context, w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	auditRec := c.MakeAuditRecord("rollbackConfig")
+	auditRec.Target = id
+	defer c.LogAuditRec(auditRec)
+
+	cfg, err := c.App.RollbackConfig(id)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	auditRec.Success()
+
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Write([]byte(cfg.ToJSON()))
+}
@@ -20,3 +20,15 @@ func (api *API) APIHandler(h func(*Context, http.ResponseWriter, *http.Request))
 	}
 	return handler
 }
+
+// AdminHandler provides a handler for API endpoints which require the request to carry the admin access token.
+func (api *API) AdminHandler(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
+	handler := &web.Handler{
+		GetGlobalAppOptions: api.GetGlobalAppOptions,
+		HandleFunc:          h,
+		HandlerName:         web.GetHandlerName(h),
+		RequireSession:      false,
+		RequireAdmin:        true,
+	}
+	return handler
+}
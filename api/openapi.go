@@ -0,0 +1,425 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/topoface/snippet-challenge/model"
+	"github.com/topoface/snippet-challenge/web"
+)
+
+const (
+	openAPIVersion = "3.0.3"
+
+	adminSecurityScheme = "adminToken"
+)
+
+// openAPIDocument is an OpenAPI 3 document, covering the parts used to describe this API.
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema         `json:"schemas"`
+	SecuritySchemes map[string]*openAPISecurityScheme `json:"securitySchemes"`
+}
+
+type openAPISecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+type openAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+	Security    []map[string][]string       `json:"security,omitempty"`
+	Parameters  []*openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	MinLength            *int                      `json:"minLength,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+// operationDoc documents the operation of a handler. The path, method and path parameters of the
+// operation come from the route it is registered on.
+type operationDoc struct {
+	Summary  string
+	Tag      string
+	Request  interface{} // The model of the JSON request body, if any
+	Response interface{} // The model of the JSON response body, if any
+	Status   int         // The status of a successful response, 200 by default
+	Query    []queryParamDoc
+	Errors   []*model.ErrorKind
+
+	// The media types of a request or response body that is not JSON, such as a file, if any.
+	RawRequest  string
+	RawResponse string
+}
+
+type queryParamDoc struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+}
+
+// buildOpenAPI generates the OpenAPI document of every route registered on the root router. Routes
+// whose handler has no entry in operationDocs are left out.
+func (api *API) buildOpenAPI() *openAPIDocument {
+	schemas := newSchemaRegistry()
+	doc := &openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info: openAPIInfo{
+			Title:       "Snippet API",
+			Description: "The paths at the root are a deprecated alias of " + model.API_URL_SUFFIX_V1 + ".",
+			Version:     "1.0",
+		},
+		Paths: map[string]map[string]*openAPIOperation{},
+		Components: openAPIComponents{
+			Schemas: schemas.schemas,
+			SecuritySchemes: map[string]*openAPISecurityScheme{
+				adminSecurityScheme: {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+
+	schemas.schemas["AppError"] = appErrorSchema()
+	schemas.schemaOf(reflect.TypeOf(model.Problem{}))
+
+	api.BaseRoutes.Root.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		handler, ok := route.GetHandler().(*web.Handler)
+		if !ok {
+			return nil
+		}
+
+		opDoc, ok := operationDocs[handler.HandlerName]
+		if !ok {
+			return nil
+		}
+
+		path, err := openAPIPath(route)
+		if err != nil {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{http.MethodGet}
+		}
+
+		deprecated := false
+		for _, ancestor := range ancestors {
+			if ancestor == api.rootAlias {
+				deprecated = true
+			}
+		}
+
+		for _, method := range methods {
+			op := opDoc.operation(schemas, handler, path, deprecated)
+			if doc.Paths[path] == nil {
+				doc.Paths[path] = map[string]*openAPIOperation{}
+			}
+			doc.Paths[path][strings.ToLower(method)] = op
+		}
+
+		return nil
+	})
+
+	return doc
+}
+
+// openAPIPath returns the OpenAPI path of a route. A route matching a path prefix gets a trailing
+// path parameter for the rest of the path.
+func openAPIPath(route *mux.Route) (string, error) {
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return "", err
+	}
+
+	regexp, err := route.GetPathRegexp()
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasSuffix(regexp, "$") {
+		template = strings.TrimSuffix(template, "/") + "/{path}"
+	}
+
+	return template, nil
+}
+
+func (d *operationDoc) operation(schemas *schemaRegistry, handler *web.Handler, path string, deprecated bool) *openAPIOperation {
+	op := &openAPIOperation{
+		OperationID: handler.HandlerName,
+		Summary:     d.Summary,
+		Deprecated:  deprecated,
+		Responses:   map[string]*openAPIResponse{},
+	}
+	if deprecated {
+		op.OperationID += "Deprecated"
+	}
+	if d.Tag != "" {
+		op.Tags = []string{d.Tag}
+	}
+
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			op.Parameters = append(op.Parameters, &openAPIParameter{
+				Name:     strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}"),
+				In:       "path",
+				Required: true,
+				Schema:   &openAPISchema{Type: "string"},
+			})
+		}
+	}
+
+	for _, param := range d.Query {
+		op.Parameters = append(op.Parameters, &openAPIParameter{
+			Name:        param.Name,
+			In:          "query",
+			Description: param.Description,
+			Required:    param.Required,
+			Schema:      &openAPISchema{Type: param.Type},
+		})
+	}
+
+	if d.Request != nil {
+		op.RequestBody = &openAPIRequestBody{
+			Required: true,
+			Content:  jsonContent(schemas.schemaOf(reflect.TypeOf(d.Request))),
+		}
+	} else if d.RawRequest != "" {
+		op.RequestBody = &openAPIRequestBody{
+			Required: true,
+			Content:  rawContent(d.RawRequest),
+		}
+	}
+
+	status := d.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := &openAPIResponse{Description: http.StatusText(status)}
+	if d.Response != nil {
+		response.Content = jsonContent(schemas.schemaOf(reflect.TypeOf(d.Response)))
+	} else if d.RawResponse != "" {
+		response.Content = rawContent(d.RawResponse)
+	}
+	op.Responses[strconv.Itoa(status)] = response
+
+	errs := d.Errors
+	if handler.RequireAdmin {
+		op.Security = []map[string][]string{{adminSecurityScheme: {}}}
+		errs = append([]*model.ErrorKind{model.ErrNotAuthenticated, model.ErrPermissionDenied}, errs...)
+	}
+	for status, codes := range errorCodesByStatus(errs) {
+		op.Responses[strconv.Itoa(status)] = &openAPIResponse{
+			Description: strings.Join(codes, ", "),
+			Content: map[string]*openAPIMediaType{
+				"application/json":         {Schema: &openAPISchema{Ref: "#/components/schemas/AppError"}},
+				model.PROBLEM_CONTENT_TYPE: {Schema: &openAPISchema{Ref: "#/components/schemas/Problem"}},
+			},
+		}
+	}
+
+	return op
+}
+
+func errorCodesByStatus(kinds []*model.ErrorKind) map[int][]string {
+	codes := map[int][]string{}
+	for _, kind := range kinds {
+		codes[kind.Status] = append(codes[kind.Status], kind.Code)
+	}
+	return codes
+}
+
+func jsonContent(schema *openAPISchema) map[string]*openAPIMediaType {
+	return map[string]*openAPIMediaType{"application/json": {Schema: schema}}
+}
+
+// rawContent describes a body of the given media type, which is text or else binary.
+func rawContent(mediaType string) map[string]*openAPIMediaType {
+	schema := &openAPISchema{Type: "string"}
+	if !strings.HasPrefix(mediaType, "text/") {
+		schema.Format = "binary"
+	}
+	return map[string]*openAPIMediaType{mediaType: {Schema: schema}}
+}
+
+// appErrorSchema describes the body written by AppError.ToJSON, which does not follow the fields
+// of AppError.
+func appErrorSchema() *openAPISchema {
+	codes := []string{}
+	for _, kind := range model.ErrorKinds() {
+		codes = append(codes, kind.Code)
+	}
+
+	return &openAPISchema{
+		Type: "object",
+		Properties: map[string]*openAPISchema{
+			"error": {
+				Type:        "string",
+				Description: "The machine code of the error, one of " + strings.Join(codes, ", ") + ", or the HTTP status text of other errors.",
+			},
+			"data": {
+				Type: "object",
+				Properties: map[string]*openAPISchema{
+					"detail": {Type: "string", Description: "The error message, translated for the Accept-Language of the request."},
+				},
+			},
+			"request_id":     {Type: "string"},
+			"detailed_error": {Type: "string", Description: "Debugging details, only sent in developer mode."},
+		},
+		Required: []string{"error"},
+	}
+}
+
+// schemaRegistry derives schemas from model types, adding structs to the document components.
+type schemaRegistry struct {
+	schemas map[string]*openAPISchema
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{schemas: map[string]*openAPISchema{}}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (r *schemaRegistry) schemaOf(t reflect.Type) *openAPISchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct:
+		return r.structSchema(t)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &openAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &openAPISchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &openAPISchema{Type: "array", Items: r.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: r.schemaOf(t.Elem())}
+	default:
+		return &openAPISchema{}
+	}
+}
+
+// structSchema adds the schema of the struct to the components, once, and refers to it. Fields
+// are named by their json tags, and the validate tags mark them required or non-blank.
+func (r *schemaRegistry) structSchema(t reflect.Type) *openAPISchema {
+	ref := &openAPISchema{Ref: "#/components/schemas/" + t.Name()}
+	if _, ok := r.schemas[t.Name()]; ok {
+		return ref
+	}
+
+	schema := &openAPISchema{Type: "object", Properties: map[string]*openAPISchema{}}
+	r.schemas[t.Name()] = schema
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if tagName := strings.Split(tag, ",")[0]; tagName != "" {
+				name = tagName
+			}
+		}
+
+		property := r.schemaOf(field.Type)
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ";") {
+			switch rule {
+			case "required":
+				schema.Required = append(schema.Required, name)
+			case "blank:false":
+				minLength := 1
+				property.MinLength = &minLength
+			}
+		}
+		schema.Properties[name] = property
+	}
+	sort.Strings(schema.Required)
+
+	return ref
+}
+
+func (api *API) InitOpenAPI() {
+	api.BaseRoutes.APIRoot.Handle("/openapi.json", api.APIHandler(api.getOpenAPI)).Methods("GET")
+}
+
+func (api *API) getOpenAPI(c *Context, w http.ResponseWriter, r *http.Request) {
+	w.Write(api.openAPI)
+}
+
+func marshalOpenAPI(doc *openAPIDocument) []byte {
+	b, _ := json.Marshal(doc)
+	return b
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+// operationDocs documents the operations of the API by handler name. Every registered handler
+// needs an entry to appear in the OpenAPI document.
+var operationDocs = map[string]*operationDoc{
+	"createSnippet": {
+		Summary:  "Create a snippet",
+		Tag:      "snippets",
+		Request:  model.SnippetRequest{},
+		Response: model.Snippet{},
+		Status:   http.StatusCreated,
+		Errors:   []*model.ErrorKind{model.ErrValidation, model.ErrParse, model.ErrSnippetNameConflict, model.ErrUnavailable},
+	},
+	"getSnippet": {
+		Summary:  "Get a snippet, extending its expiry",
+		Tag:      "snippets",
+		Response: model.Snippet{},
+		Errors:   []*model.ErrorKind{model.ErrSnippetNotFound, model.ErrSnippetExpired, model.ErrUnavailable},
+	},
+	"getSnippets": {
+		Summary:  "List the live snippets, sorted by name",
+		Tag:      "snippets",
+		Response: []model.Snippet{},
+		Query: []queryParamDoc{
+			{Name: "page", Type: "integer"},
+			{Name: "per_page", Type: "integer"},
+		},
+		Errors: []*model.ErrorKind{model.ErrValidation},
+	},
+	"updateSnippet": {
+		Summary:  "Change the body or the expiry of a snippet",
+		Tag:      "snippets",
+		Request:  model.SnippetPatch{},
+		Response: model.Snippet{},
+		Errors:   []*model.ErrorKind{model.ErrValidation, model.ErrParse, model.ErrSnippetNotFound, model.ErrSnippetExpired, model.ErrUnavailable},
+	},
+	"deleteSnippet": {
+		Summary: "Delete a snippet and its attachments",
+		Tag:     "snippets",
+		Status:  http.StatusNoContent,
+		Errors:  []*model.ErrorKind{model.ErrSnippetNotFound, model.ErrSnippetExpired, model.ErrUnavailable},
+	},
+	"getSnippetRaw": {
+		Summary:     "Get the body of a snippet as plain text, extending its expiry",
+		Tag:         "snippets",
+		RawResponse: "text/plain",
+		Errors:      []*model.ErrorKind{model.ErrSnippetNotFound, model.ErrSnippetExpired, model.ErrUnavailable},
+	},
+	"getSnippetAttachments": {
+		Summary:  "List the attachments of a snippet",
+		Tag:      "snippets",
+		Response: []model.SnippetAttachment{},
+		Errors:   []*model.ErrorKind{model.ErrSnippetNotFound, model.ErrSnippetExpired},
+	},
+	"uploadSnippetAttachment": {
+		Summary:    "Attach the request body to a snippet, replacing the attachment with the same name",
+		Tag:        "snippets",
+		RawRequest: "application/octet-stream",
+		Response:   model.SnippetAttachment{},
+		Errors:     []*model.ErrorKind{model.ErrValidation, model.ErrSnippetNotFound, model.ErrSnippetExpired, model.ErrSnippetTooLarge, model.ErrUnavailable},
+	},
+	"getSnippetAttachment": {
+		Summary:     "Download an attachment of a snippet",
+		Tag:         "snippets",
+		RawResponse: "application/octet-stream",
+		Errors:      []*model.ErrorKind{model.ErrNotFound, model.ErrSnippetNotFound, model.ErrSnippetExpired},
+	},
+	"deleteSnippetAttachment": {
+		Summary: "Delete an attachment of a snippet",
+		Tag:     "snippets",
+		Status:  http.StatusNoContent,
+		Errors:  []*model.ErrorKind{model.ErrNotFound, model.ErrSnippetNotFound, model.ErrSnippetExpired, model.ErrUnavailable},
+	},
+	"createSnippetV2": {
+		Summary:  "Create a snippet",
+		Tag:      "snippets",
+		Request:  model.SnippetRequestV2{},
+		Response: model.SnippetV2{},
+		Status:   http.StatusCreated,
+		Errors:   []*model.ErrorKind{model.ErrValidation, model.ErrParse, model.ErrSnippetNameConflict, model.ErrUnavailable},
+	},
+	"getSnippetV2": {
+		Summary:  "Get a snippet, extending its expiry",
+		Tag:      "snippets",
+		Response: model.SnippetV2{},
+		Errors:   []*model.ErrorKind{model.ErrSnippetNotFound, model.ErrSnippetExpired, model.ErrUnavailable},
+	},
+	"getConfig": {
+		Summary:  "Get the configuration, with secrets masked",
+		Tag:      "config",
+		Response: model.Config{},
+	},
+	"patchConfig": {
+		Summary:  "Change some settings of the configuration",
+		Tag:      "config",
+		Request:  model.Config{},
+		Response: model.Config{},
+		Errors:   []*model.ErrorKind{model.ErrValidation},
+	},
+	"reloadConfig": {
+		Summary: "Reload the configuration from its store",
+		Tag:     "config",
+		Status:  http.StatusNoContent,
+	},
+	"getEnvironmentConfig": {
+		Summary:  "Get the settings overridden by environment variables",
+		Tag:      "config",
+		Response: map[string]interface{}{},
+	},
+	"getConfigHistory": {
+		Summary:  "List the recorded configuration changes",
+		Tag:      "config",
+		Response: []model.ConfigHistoryEntry{},
+		Errors:   []*model.ErrorKind{model.ErrUnavailable},
+	},
+	"getConfigHistoryDiff": {
+		Summary:  "Compare two recorded configurations",
+		Tag:      "config",
+		Response: model.ConfigDiffs{},
+		Query: []queryParamDoc{
+			{Name: "from", Type: "string", Description: "The ID of the older history entry", Required: true},
+			{Name: "to", Type: "string", Description: "The ID of the newer history entry, the current configuration by default"},
+		},
+		Errors: []*model.ErrorKind{model.ErrValidation, model.ErrNotFound, model.ErrUnavailable},
+	},
+	"rollbackConfig": {
+		Summary:  "Restore a recorded configuration",
+		Tag:      "config",
+		Response: model.Config{},
+		Errors:   []*model.ErrorKind{model.ErrValidation, model.ErrNotFound, model.ErrUnavailable},
+	},
+	"getAudits": {
+		Summary:  "List audit records, newest first",
+		Tag:      "audits",
+		Response: []model.Audit{},
+		Query: []queryParamDoc{
+			{Name: "event", Type: "string"},
+			{Name: "actor", Type: "string"},
+			{Name: "target", Type: "string"},
+			{Name: "status", Type: "string"},
+			{Name: "since", Type: "integer", Description: "The earliest creation time in milliseconds"},
+			{Name: "until", Type: "integer", Description: "The latest creation time in milliseconds"},
+			{Name: "page", Type: "integer"},
+			{Name: "per_page", Type: "integer"},
+		},
+		Errors: []*model.ErrorKind{model.ErrValidation},
+	},
+	"getHealth": {
+		Summary:  "Check that the server is alive",
+		Tag:      "system",
+		Response: map[string]string{},
+	},
+	"getReadiness": {
+		Summary:  "Check that the server and its dependencies are ready",
+		Tag:      "system",
+		Response: model.ReadinessReport{},
+	},
+	"serveDebug": {
+		Summary: "Serve the pprof and runtime stats endpoints in developer mode",
+		Tag:     "system",
+		Errors:  []*model.ErrorKind{model.ErrNotFound},
+	},
+	"getOpenAPI": {
+		Summary:  "Get this OpenAPI document",
+		Tag:      "system",
+		Response: map[string]interface{}{},
+	},
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/web"
+)
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	api := Init(nil, nil, mux.NewRouter())
+
+	var doc struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Required   []string                          `json:"required"`
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(api.openAPI, &doc))
+
+	routes := 0
+	err := api.BaseRoutes.Root.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		handler, ok := route.GetHandler().(*web.Handler)
+		if !ok {
+			return nil
+		}
+		routes++
+
+		path, err := openAPIPath(route)
+		require.NoError(t, err)
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{http.MethodGet}
+		}
+
+		for _, method := range methods {
+			_, ok := doc.Paths[path][strings.ToLower(method)]
+			assert.True(t, ok, "%s %s (%s) is missing from the OpenAPI document, document it in operationDocs", method, path, handler.HandlerName)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotZero(t, routes)
+
+	assert.Contains(t, doc.Paths, "/api/v1/openapi.json")
+	assert.Contains(t, doc.Paths, "/snippets/{name}", "the deprecated root alias is documented")
+	assert.Contains(t, doc.Paths, "/debug/{path}")
+
+	snippetRequest := doc.Components.Schemas["SnippetRequest"]
+	assert.Equal(t, []string{"name", "snippet"}, snippetRequest.Required)
+	assert.EqualValues(t, 1, snippetRequest.Properties["name"]["minLength"])
+	assert.Contains(t, doc.Components.Schemas, "Snippet")
+	assert.Contains(t, doc.Components.Schemas, "AppError")
+	assert.Contains(t, doc.Components.Schemas, "Problem")
+}
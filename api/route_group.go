@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// RouteGroup registers routes on several routers at once, so that the same endpoints can be
+// served under more than one prefix, such as a versioned prefix and its deprecated alias.
+type RouteGroup struct {
+	routers []*mux.Router
+}
+
+// NewRouteGroup creates a route group registering its routes on all of the given routers.
+func NewRouteGroup(routers ...*mux.Router) *RouteGroup {
+	return &RouteGroup{routers: routers}
+}
+
+// PathPrefix returns a group of subrouters for the given path prefix of each router.
+func (g *RouteGroup) PathPrefix(prefix string) *RouteGroup {
+	routers := make([]*mux.Router, len(g.routers))
+	for i, router := range g.routers {
+		routers[i] = router.PathPrefix(prefix).Subrouter()
+	}
+	return NewRouteGroup(routers...)
+}
+
+// Handle registers a route for the path on each router.
+func (g *RouteGroup) Handle(path string, handler http.Handler) *GroupRoute {
+	routes := make([]*mux.Route, len(g.routers))
+	for i, router := range g.routers {
+		routes[i] = router.Handle(path, handler)
+	}
+	return &GroupRoute{routes: routes}
+}
+
+// GroupRoute is a route registered by a RouteGroup on each of its routers.
+type GroupRoute struct {
+	routes []*mux.Route
+}
+
+// Methods restricts the route to the given HTTP methods.
+func (r *GroupRoute) Methods(methods ...string) *GroupRoute {
+	for _, route := range r.routes {
+		route.Methods(methods...)
+	}
+	return r
+}
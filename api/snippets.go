@@ -1,7 +1,10 @@
 package api
 
 import (
+	"io/ioutil"
+	"mime"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/topoface/snippet-challenge/binding"
@@ -10,15 +13,30 @@ import (
 
 func (api *API) InitSnippets() {
 	api.BaseRoutes.Snippets.Handle("", api.APIHandler(createSnippet)).Methods("POST")
+	api.BaseRoutes.Snippets.Handle("", api.APIHandler(getSnippets)).Methods("GET")
 	api.BaseRoutes.Snippets.Handle("/{name}", api.APIHandler(getSnippet)).Methods("GET")
+	api.BaseRoutes.Snippets.Handle("/{name}", api.APIHandler(updateSnippet)).Methods("PUT")
+	api.BaseRoutes.Snippets.Handle("/{name}", api.APIHandler(deleteSnippet)).Methods("DELETE")
+	api.BaseRoutes.Snippets.Handle("/{name}/raw", api.APIHandler(getSnippetRaw)).Methods("GET")
+	api.BaseRoutes.Snippets.Handle("/{name}/attachments", api.APIHandler(getSnippetAttachments)).Methods("GET")
+	api.BaseRoutes.Snippets.Handle("/{name}/attachments/{attachment_name}", api.APIHandler(uploadSnippetAttachment)).Methods("PUT")
+	api.BaseRoutes.Snippets.Handle("/{name}/attachments/{attachment_name}", api.APIHandler(getSnippetAttachment)).Methods("GET")
+	api.BaseRoutes.Snippets.Handle("/{name}/attachments/{attachment_name}", api.APIHandler(deleteSnippetAttachment)).Methods("DELETE")
+
+	api.BaseRoutes.SnippetsV2.Handle("", api.APIHandler(createSnippetV2)).Methods("POST")
+	api.BaseRoutes.SnippetsV2.Handle("/{name}", api.APIHandler(getSnippetV2)).Methods("GET")
 }
 
 func createSnippet(c *Context, w http.ResponseWriter, r *http.Request) {
+	auditRec := c.MakeAuditRecord("createSnippet")
+	defer c.LogAuditRec(auditRec)
+
 	var snippetRequest model.SnippetRequest
 	if _, err := binding.JSON.Bind(r, &snippetRequest); err != nil {
 		c.Err = err
 		return
 	}
+	auditRec.Target = snippetRequest.Name
 
 	snippet, err := c.App.CreateSnippet(&snippetRequest)
 	if err != nil {
@@ -26,6 +44,8 @@ func createSnippet(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	auditRec.Success()
+
 	w.WriteHeader(http.StatusCreated)
 	w.Write([]byte(snippet.ToJSON()))
 }
@@ -34,7 +54,7 @@ func getSnippet(c *Context, w http.ResponseWriter, r *http.Request) {
 	props := mux.Vars(r)
 	snippetName, ok := props["name"]
 	if !ok {
-		c.Err = model.ValidationError("", "No snippet name", nil, "")
+		c.Err = model.NewInvalidUrlParamError("name")
 		return
 	}
 
@@ -46,3 +66,196 @@ func getSnippet(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	w.Write([]byte(snippet.ToJSON()))
 }
+
+// getSnippets returns a page of the live snippets, sorted by name, as selected by the page and
+// per_page query parameters.
+func getSnippets(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	query := &model.SnippetQuery{}
+	for name, dest := range map[string]*int{"page": &query.Page, "per_page": &query.PerPage} {
+		if value := params.Get(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				c.Err = model.NewInvalidUrlParamError(name)
+				return
+			}
+			*dest = parsed
+		}
+	}
+
+	snippets, err := c.App.GetSnippets(query)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(model.SnippetListToJSON(snippets)))
+}
+
+func updateSnippet(c *Context, w http.ResponseWriter, r *http.Request) {
+	snippetName := mux.Vars(r)["name"]
+
+	auditRec := c.MakeAuditRecord("updateSnippet")
+	auditRec.Target = snippetName
+	defer c.LogAuditRec(auditRec)
+
+	var patch model.SnippetPatch
+	if _, err := binding.JSON.Bind(r, &patch); err != nil {
+		c.Err = err
+		return
+	}
+
+	snippet, err := c.App.UpdateSnippet(snippetName, &patch)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	auditRec.Success()
+
+	w.Write([]byte(snippet.ToJSON()))
+}
+
+func deleteSnippet(c *Context, w http.ResponseWriter, r *http.Request) {
+	snippetName := mux.Vars(r)["name"]
+
+	auditRec := c.MakeAuditRecord("deleteSnippet")
+	auditRec.Target = snippetName
+	defer c.LogAuditRec(auditRec)
+
+	if err := c.App.DeleteSnippet(snippetName); err != nil {
+		c.Err = err
+		return
+	}
+
+	auditRec.Success()
+
+	ReturnStatusNoContent(w)
+}
+
+// getSnippetRaw returns the body of a snippet as plain text, extending its expiry like getSnippet.
+func getSnippetRaw(c *Context, w http.ResponseWriter, r *http.Request) {
+	snippet, err := c.App.GetSnippet(mux.Vars(r)["name"])
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write([]byte(snippet.Body))
+}
+
+func getSnippetAttachments(c *Context, w http.ResponseWriter, r *http.Request) {
+	attachments, err := c.App.GetSnippetAttachments(mux.Vars(r)["name"])
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(model.SnippetAttachmentListToJSON(attachments)))
+}
+
+// uploadSnippetAttachment attaches the request body to a snippet, with the content type of the
+// request.
+func uploadSnippetAttachment(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := mux.Vars(r)
+
+	auditRec := c.MakeAuditRecord("uploadSnippetAttachment")
+	auditRec.Target = props["name"] + "/" + props["attachment_name"]
+	defer c.LogAuditRec(auditRec)
+
+	// Reading one byte past the limit lets the app tell an attachment that is too large.
+	maxSize := *c.App.Config().FileSettings.MaxFileSize
+	data, readErr := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxSize+1))
+	if readErr != nil && int64(len(data)) <= maxSize {
+		c.Err = model.NewAppError("uploadSnippetAttachment", "api.snippet.upload_attachment.read_body.app_error", nil, readErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	attachment, err := c.App.UploadSnippetAttachment(props["name"], props["attachment_name"], r.Header.Get("Content-Type"), data)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	auditRec.Success()
+
+	w.Write([]byte(attachment.ToJSON()))
+}
+
+// getSnippetAttachment returns the content of an attachment, as a download so that browsers do
+// not render it.
+func getSnippetAttachment(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := mux.Vars(r)
+
+	attachment, data, err := c.App.GetSnippetAttachment(props["name"], props["attachment_name"])
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(data)
+}
+
+func deleteSnippetAttachment(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := mux.Vars(r)
+
+	auditRec := c.MakeAuditRecord("deleteSnippetAttachment")
+	auditRec.Target = props["name"] + "/" + props["attachment_name"]
+	defer c.LogAuditRec(auditRec)
+
+	if err := c.App.DeleteSnippetAttachment(props["name"], props["attachment_name"]); err != nil {
+		c.Err = err
+		return
+	}
+
+	auditRec.Success()
+
+	ReturnStatusNoContent(w)
+}
+
+func createSnippetV2(c *Context, w http.ResponseWriter, r *http.Request) {
+	auditRec := c.MakeAuditRecord("createSnippet")
+	defer c.LogAuditRec(auditRec)
+
+	var snippetRequest model.SnippetRequestV2
+	if _, err := binding.JSON.Bind(r, &snippetRequest); err != nil {
+		c.Err = err
+		return
+	}
+	auditRec.Target = snippetRequest.Name
+
+	if err := snippetRequest.IsValid(); err != nil {
+		c.Err = err
+		return
+	}
+
+	snippet, err := c.App.CreateSnippet(snippetRequest.ToV1())
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	auditRec.Success()
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(snippet.ToV2(c.App.GetSnippetURLV2(snippet.Name)).ToJSON()))
+}
+
+func getSnippetV2(c *Context, w http.ResponseWriter, r *http.Request) {
+	snippetName := mux.Vars(r)["name"]
+
+	snippet, err := c.App.GetSnippet(snippetName)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(snippet.ToV2(c.App.GetSnippetURLV2(snippet.Name)).ToJSON()))
+}
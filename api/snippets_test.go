@@ -0,0 +1,276 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+// createSnippet creates a snippet with the given name and body through the API.
+func (th *TestHelper) createSnippet(t *testing.T, name, body string) {
+	t.Helper()
+
+	request := &model.SnippetRequest{Name: name, Body: body, ExpiresIn: 60}
+	b, _ := json.Marshal(request)
+	resp, respBody := th.MakeRequest(t, http.MethodPost, "/api/v1/snippets", string(b), nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, respBody)
+}
+
+// attachmentFiles returns the files stored for the attachments of every snippet.
+func (th *TestHelper) attachmentFiles(t *testing.T) []string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(th.DataDir, "snippets", "*", "attachments", "*"))
+	require.NoError(t, err)
+	return files
+}
+
+func TestCreateSnippet(t *testing.T) {
+	th := Setup(t, func(cfg *model.Config) {
+		*cfg.ServiceSettings.SiteURL = "https://example.com"
+	})
+
+	t.Run("name escaped in url", func(t *testing.T) {
+		resp, body := th.MakeRequest(t, http.MethodPost, "/api/v1/snippets", `{"name":"what? #1","expires_in":60,"snippet":"body"}`, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode, body)
+		snippet := model.SnippetFromJSON(strings.NewReader(body))
+		require.NotNil(t, snippet)
+		assert.Equal(t, "https://example.com/api/v1/snippets/what%3F%20%231", snippet.URL)
+
+		body = th.mustGet(t, strings.TrimPrefix(snippet.URL, "https://example.com"))
+		assert.Contains(t, body, `"name":"what? #1"`)
+	})
+
+	for name, request := range map[string]string{
+		"slash in name":    `{"name":"a/b","expires_in":60,"snippet":"body"}`,
+		"expiry above max": fmt.Sprintf(`{"name":"recipe","expires_in":%d,"snippet":"body"}`, uint64(model.SNIPPET_MAX_EXPIRES_IN+1)),
+		"overflow":         `{"name":"recipe","expires_in":18446744073709551615,"snippet":"body"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, body := th.MakeRequest(t, http.MethodPost, "/api/v1/snippets", request, nil)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+		})
+	}
+
+	t.Run("patched expiry above max", func(t *testing.T) {
+		th.createSnippet(t, "patched", "body")
+		resp, body := th.MakeRequest(t, http.MethodPut, "/api/v1/snippets/patched", `{"expires_in":18446744073709551615}`, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	})
+}
+
+func TestGetSnippets(t *testing.T) {
+	th := Setup(t)
+	for _, name := range []string{"c", "a", "b"} {
+		th.createSnippet(t, name, "body of "+name)
+	}
+
+	names := func(body string) []string {
+		var snippets []*model.Snippet
+		require.NoError(t, json.Unmarshal([]byte(body), &snippets))
+		names := []string{}
+		for _, snippet := range snippets {
+			names = append(names, snippet.Name)
+		}
+		return names
+	}
+
+	resp, body := th.MakeRequest(t, http.MethodGet, "/api/v1/snippets", "", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	assert.Equal(t, []string{"a", "b", "c"}, names(body))
+
+	resp, body = th.MakeRequest(t, http.MethodGet, "/api/v1/snippets?page=1&per_page=2", "", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	assert.Equal(t, []string{"c"}, names(body))
+
+	resp, body = th.MakeRequest(t, http.MethodGet, "/api/v1/snippets?page=5", "", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	assert.Equal(t, "[]", body)
+
+	resp, _ = th.MakeRequest(t, http.MethodGet, "/api/v1/snippets?per_page=x", "", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestUpdateSnippet(t *testing.T) {
+	th := Setup(t)
+	th.createSnippet(t, "recipe", "1 cup flour")
+
+	resp, body := th.MakeRequest(t, http.MethodPut, "/api/v1/snippets/recipe", `{"snippet":"2 cups flour"}`, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	snippet := model.SnippetFromJSON(strings.NewReader(body))
+	require.NotNil(t, snippet)
+	assert.Equal(t, "2 cups flour", snippet.Body)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), snippet.ExpiresAt, 5*time.Second, "the expiry is kept")
+
+	resp, body = th.MakeRequest(t, http.MethodPut, "/api/v1/snippets/recipe", `{"expires_in":3600}`, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	snippet = model.SnippetFromJSON(strings.NewReader(body))
+	require.NotNil(t, snippet)
+	assert.Equal(t, "2 cups flour", snippet.Body, "the body is kept")
+	assert.WithinDuration(t, time.Now().Add(time.Hour), snippet.ExpiresAt, 5*time.Second)
+
+	resp, _ = th.MakeRequest(t, http.MethodPut, "/api/v1/snippets/recipe", `{"snippet":" "}`, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = th.MakeRequest(t, http.MethodPut, "/api/v1/snippets/missing", `{"snippet":"body"}`, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	audits := th.getAudits(t, "event=updateSnippet&target=recipe")
+	require.Len(t, audits, 3)
+	assert.Equal(t, model.AUDIT_STATUS_FAIL, audits[0].Status)
+	assert.Equal(t, model.AUDIT_STATUS_SUCCESS, audits[1].Status)
+}
+
+func TestDeleteSnippet(t *testing.T) {
+	th := Setup(t)
+	th.createSnippet(t, "recipe", "1 cup flour")
+
+	resp, body := th.MakeRequest(t, http.MethodPut, "/api/v1/snippets/recipe/attachments/photo.png", "image", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	require.Len(t, th.attachmentFiles(t), 1)
+
+	resp, _ = th.MakeRequest(t, http.MethodDelete, "/api/v1/snippets/recipe", "", nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Empty(t, th.attachmentFiles(t), "the attachments are removed with the snippet")
+
+	resp, _ = th.MakeRequest(t, http.MethodGet, "/api/v1/snippets/recipe", "", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = th.MakeRequest(t, http.MethodDelete, "/api/v1/snippets/recipe", "", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// The name can be reused right away.
+	th.createSnippet(t, "recipe", "again")
+}
+
+func TestGetSnippetRaw(t *testing.T) {
+	th := Setup(t)
+	th.createSnippet(t, "page", "<script>alert(1)</script>")
+
+	resp, body := th.MakeRequest(t, http.MethodGet, "/api/v1/snippets/page/raw", "", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	assert.Equal(t, "<script>alert(1)</script>", body)
+	assert.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"))
+
+	resp, body = th.MakeRequest(t, http.MethodGet, "/api/v1/snippets/missing/raw", "", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"), "errors are still JSON")
+	assert.Contains(t, body, "SnippetNotFound")
+}
+
+func TestSnippetAttachments(t *testing.T) {
+	th := Setup(t, func(cfg *model.Config) {
+		*cfg.FileSettings.MaxFileSize = 16
+	})
+	th.createSnippet(t, "recipe", "1 cup flour")
+
+	attachmentsOf := func(name string) []*model.SnippetAttachment {
+		resp, body := th.MakeRequest(t, http.MethodGet, "/api/v1/snippets/"+name+"/attachments", "", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		var attachments []*model.SnippetAttachment
+		require.NoError(t, json.Unmarshal([]byte(body), &attachments))
+		return attachments
+	}
+	assert.Empty(t, attachmentsOf("recipe"))
+
+	resp, body := th.MakeRequest(t, http.MethodPut, "/api/v1/snippets/recipe/attachments/notes.txt", "first", http.Header{"Content-Type": []string{"text/markdown"}})
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	var attachment model.SnippetAttachment
+	require.NoError(t, json.Unmarshal([]byte(body), &attachment))
+	assert.Equal(t, "notes.txt", attachment.Name)
+	assert.Equal(t, "text/markdown", attachment.ContentType)
+	assert.EqualValues(t, 5, attachment.Size)
+	assert.NotZero(t, attachment.CreateAt)
+
+	t.Run("download", func(t *testing.T) {
+		resp, body := th.MakeRequest(t, http.MethodGet, "/api/v1/snippets/recipe/attachments/notes.txt", "", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		assert.Equal(t, "first", body)
+		assert.Equal(t, "text/markdown", resp.Header.Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=notes.txt`, resp.Header.Get("Content-Disposition"))
+		assert.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"))
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		resp, body := th.MakeRequest(t, http.MethodPut, "/api/v1/snippets/recipe/attachments/notes.txt", "second", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+
+		attachments := attachmentsOf("recipe")
+		require.Len(t, attachments, 1)
+		assert.EqualValues(t, 6, attachments[0].Size)
+		assert.Equal(t, "text/plain; charset=utf-8", attachments[0].ContentType, "detected from the content")
+
+		files := th.attachmentFiles(t)
+		require.Len(t, files, 1, "the replaced file is removed")
+		data, err := ioutil.ReadFile(files[0])
+		require.NoError(t, err)
+		assert.Equal(t, "second", string(data))
+
+		snippet := model.SnippetFromJSON(strings.NewReader(th.mustGet(t, "/api/v1/snippets/recipe")))
+		require.NotNil(t, snippet)
+		require.Len(t, snippet.Attachments, 1, "attachments are listed with the snippet")
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		resp, body := th.MakeRequest(t, http.MethodPut, "/api/v1/snippets/recipe/attachments/big.bin", strings.Repeat("x", 17), nil)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+		assert.Contains(t, body, "SnippetTooLarge")
+
+		resp, body = th.MakeRequest(t, http.MethodPut, "/api/v1/snippets/recipe/attachments/max.bin", strings.Repeat("x", 16), nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, body)
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		// Dot segments never reach the handler, as the router redirects to the cleaned path.
+		for _, name := range []string{"a%5Cb", "%20", "line%0Abreak"} {
+			resp, _ := th.MakeRequest(t, http.MethodPut, "/api/v1/snippets/recipe/attachments/"+name, "data", nil)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		resp, body := th.MakeRequest(t, http.MethodGet, "/api/v1/snippets/recipe/attachments/missing", "", nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Contains(t, body, `"error":"NotFound"`)
+
+		resp, _ = th.MakeRequest(t, http.MethodPut, "/api/v1/snippets/missing/attachments/notes.txt", "data", nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		resp, _ := th.MakeRequest(t, http.MethodDelete, "/api/v1/snippets/recipe/attachments/notes.txt", "", nil)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp, _ = th.MakeRequest(t, http.MethodGet, "/api/v1/snippets/recipe/attachments/notes.txt", "", nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp, _ = th.MakeRequest(t, http.MethodDelete, "/api/v1/snippets/recipe/attachments/notes.txt", "", nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		for _, file := range th.attachmentFiles(t) {
+			data, err := ioutil.ReadFile(file)
+			require.NoError(t, err)
+			assert.NotEqual(t, "second", string(data), "the file of the deleted attachment is removed")
+		}
+	})
+}
+
+// mustGet returns the body of a successful GET request to the path.
+func (th *TestHelper) mustGet(t *testing.T, path string) string {
+	t.Helper()
+
+	resp, body := th.MakeRequest(t, http.MethodGet, path, "", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	return body
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+func (api *API) InitSystem() {
+	api.BaseRoutes.Root.Handle("/healthz", api.APIHandler(getHealth)).Methods("GET")
+	api.BaseRoutes.Root.Handle("/readyz", api.APIHandler(getReadiness)).Methods("GET")
+
+	api.BaseRoutes.Root.PathPrefix("/debug/").Handler(api.APIHandler(serveDebug))
+}
+
+func getHealth(c *Context, w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(model.MapToJSON(map[string]string{model.STATUS: model.STATUS_OK})))
+}
+
+func getReadiness(c *Context, w http.ResponseWriter, r *http.Request) {
+	report := c.App.GetReadinessReport()
+	if !report.IsReady() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	w.Write([]byte(report.ToJSON()))
+}
+
+// serveDebug exposes the pprof and runtime stats endpoints while developer mode is enabled, which
+// is checked on every request so that they can be switched on and off without a restart.
+func serveDebug(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !*c.App.Config().ServiceSettings.EnableDeveloper {
+		c.Err = model.ErrNotFound.NewWithID("serveDebug", "api.debug.disabled.app_error", nil, "")
+		return
+	}
+
+	// Let the debug handlers pick their own content types.
+	w.Header().Del("Content-Type")
+	c.App.DebugHandler().ServeHTTP(w, r)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+func TestGetReadiness(t *testing.T) {
+	th := Setup(t)
+
+	resp, body := th.MakeRequest(t, http.MethodGet, "/readyz", "", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"status":"OK","dependencies":{"store":"OK","config":"OK","file_backend":"OK"}}`, body)
+
+	t.Run("failing dependency", func(t *testing.T) {
+		// A file where the storage directory should be makes the file backend unusable.
+		notADirectory := filepath.Join(*th.Server.Config().FileSettings.Directory, "file")
+		require.NoError(t, ioutil.WriteFile(notADirectory, []byte{}, 0600))
+		th.Server.UpdateConfig(func(cfg *model.Config) {
+			*cfg.FileSettings.Directory = notADirectory
+		})
+
+		resp, body := th.MakeRequest(t, http.MethodGet, "/readyz", "", nil)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		// Only the status is reported, not the error with its paths.
+		assert.JSONEq(t, `{"status":"UNHEALTHY","dependencies":{"store":"OK","config":"OK","file_backend":"UNHEALTHY"}}`, body)
+	})
+}
+
+func TestGetReadinessConfigLoadError(t *testing.T) {
+	th := SetupWithFileStore(t)
+	valid, err := ioutil.ReadFile(th.ConfigPath)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(th.ConfigPath, []byte("{not json"), 0600))
+	require.Error(t, th.Server.ReloadConfig())
+
+	resp, body := th.MakeRequest(t, http.MethodGet, "/readyz", "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.JSONEq(t, `{"status":"UNHEALTHY","dependencies":{"store":"OK","config":"UNHEALTHY","file_backend":"OK"}}`, body)
+
+	require.NoError(t, ioutil.WriteFile(th.ConfigPath, valid, 0600))
+	require.NoError(t, th.Server.ReloadConfig())
+
+	resp, body = th.MakeRequest(t, http.MethodGet, "/readyz", "", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"status":"OK","dependencies":{"store":"OK","config":"OK","file_backend":"OK"}}`, body)
+}
+
+func TestShutdownDrainPeriod(t *testing.T) {
+	th := Setup(t, func(cfg *model.Config) {
+		*cfg.ServiceSettings.ShutdownDrainPeriod = 1
+	})
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- th.Server.Shutdown()
+	}()
+
+	// The server still answers during the drain period, reporting itself as shutting down.
+	require.Eventually(t, th.Server.IsShuttingDown, time.Second, 10*time.Millisecond)
+	resp, body := th.MakeRequest(t, http.MethodGet, "/readyz", "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Contains(t, body, model.STATUS_SHUTTING_DOWN)
+
+	select {
+	case err := <-stopped:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "the server did not stop after the drain period")
+	}
+
+	_, err := http.Get(th.SiteURL + "/readyz")
+	assert.Error(t, err, "the listener is closed after the drain period")
+}
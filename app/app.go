@@ -5,6 +5,8 @@ import (
 	"net/http"
 
 	"github.com/topoface/snippet-challenge/mlog"
+	"github.com/topoface/snippet-challenge/model"
+	"github.com/topoface/snippet-challenge/services/filestore"
 	"github.com/topoface/snippet-challenge/store"
 )
 
@@ -62,6 +64,23 @@ func (a *App) Log() *mlog.Logger {
 	return a.log
 }
 
+// Context returns the context of the request served by this app, or context.Background.
+func (a *App) Context() context.Context {
+	if a.context == nil {
+		return context.Background()
+	}
+	return a.context
+}
+
+// FileBackend returns the configured file backend, tracing its calls under the app context.
+func (a *App) FileBackend() (filestore.FileBackend, *model.AppError) {
+	backend, err := a.Srv().FileBackend()
+	if err != nil {
+		return nil, err
+	}
+	return filestore.NewTracedFileBackend(a.Context(), backend), nil
+}
+
 func (a *App) SetPath(s string) {
 	a.path = s
 }
@@ -6,13 +6,18 @@ import (
 
 	"github.com/topoface/snippet-challenge/mlog"
 	"github.com/topoface/snippet-challenge/model"
+	"github.com/topoface/snippet-challenge/services/filestore"
+	"github.com/topoface/snippet-challenge/services/metrics"
 	"github.com/topoface/snippet-challenge/store"
 )
 
 // Iface : app interface
 type Iface interface {
 	Config() *model.Config
+	Context() context.Context
+	FileBackend() (filestore.FileBackend, *model.AppError)
 	Log() *mlog.Logger
+	Metrics() *metrics.Metrics
 	Handle404(w http.ResponseWriter, r *http.Request)
 	Path() string
 	SetContext(c context.Context)
@@ -21,6 +26,27 @@ type Iface interface {
 	Srv() *Server
 	Store() *store.Store
 
+	DebugHandler() http.Handler
+	GetAudits(query *model.AuditQuery) ([]*model.Audit, *model.AppError)
+	GetConfigHistory() ([]*model.ConfigHistoryEntry, *model.AppError)
+	GetConfigHistoryDiff(fromID, toID string) (model.ConfigDiffs, *model.AppError)
+	GetEnvironmentConfig() map[string]interface{}
+	GetReadinessReport() *model.ReadinessReport
+	GetRuntimeStats() *model.RuntimeStats
+	GetSanitizedConfig() *model.Config
+	LogAuditRec(rec *model.Audit)
+	PatchConfig(patch []byte) (*model.Config, *model.AppError)
+	ReloadConfig() error
+	RollbackConfig(id string) (*model.Config, *model.AppError)
+
 	CreateSnippet(request *model.SnippetRequest) (*model.Snippet, *model.AppError)
+	DeleteSnippet(name string) *model.AppError
+	DeleteSnippetAttachment(name, attachmentName string) *model.AppError
 	GetSnippet(name string) (*model.Snippet, *model.AppError)
+	GetSnippetAttachment(name, attachmentName string) (*model.SnippetAttachment, []byte, *model.AppError)
+	GetSnippetAttachments(name string) ([]*model.SnippetAttachment, *model.AppError)
+	GetSnippets(query *model.SnippetQuery) ([]*model.Snippet, *model.AppError)
+	GetSnippetURLV2(name string) string
+	UpdateSnippet(name string, patch *model.SnippetPatch) (*model.Snippet, *model.AppError)
+	UploadSnippetAttachment(name, attachmentName, contentType string, data []byte) (*model.SnippetAttachment, *model.AppError)
 }
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"strings"
+	"time"
+
+	"github.com/topoface/snippet-challenge/config"
+	"github.com/topoface/snippet-challenge/mlog"
+	"github.com/topoface/snippet-challenge/model"
+	"github.com/topoface/snippet-challenge/utils"
+)
+
+const auditPrunerInterval = time.Hour
+
+// NewAuditLogger opens the audit log file configured by the settings, an append-only record of
+// the audited operations that survives restarts.
+func NewAuditLogger(settings *model.AuditSettings) *mlog.Logger {
+	return mlog.NewLogger(utils.MloggerConfigFromAuditConfig(settings, utils.GetAuditFileLocation))
+}
+
+// WriteAuditRec appends the audit record to the audit log file of the logger, filling in its id
+// and creation time if missing.
+func WriteAuditRec(logger *mlog.Logger, rec *model.Audit) {
+	rec.PreSave()
+
+	logger.Info("Audit",
+		mlog.String("id", rec.ID),
+		mlog.Int64("create_at", rec.CreateAt),
+		mlog.String("event", rec.Event),
+		mlog.String("actor", rec.Actor),
+		mlog.String("client_ip", rec.ClientIP),
+		mlog.String("request_id", rec.RequestID),
+		mlog.String("target", rec.Target),
+		mlog.String("status", rec.Status),
+		mlog.String("error", rec.Error),
+	)
+}
+
+// LogAuditRec appends the audit record to the audit log file and saves it for the audit API,
+// unless auditing is disabled. Failing to save it is logged rather than failing the audited
+// operation, which has already happened.
+func (a *App) LogAuditRec(rec *model.Audit) {
+	if !*a.Config().AuditSettings.Enable {
+		return
+	}
+
+	WriteAuditRec(a.Srv().auditLog, rec)
+
+	if err := a.Store().Audit().Save(a.Context(), rec); err != nil {
+		mlog.Error("Failed to save audit record",
+			mlog.String("event", rec.Event),
+			mlog.String("target", rec.Target),
+			mlog.String("request_id", rec.RequestID),
+			mlog.Err(err),
+		)
+	}
+}
+
+// auditConfigChange records a change of the active configuration, whichever way it was made: a
+// request to the config API, which is audited on its own as well, an edited config file or a
+// config database updated by another node or by the command line.
+func (s *Server) auditConfigChange(oldCfg, newCfg *model.Config) {
+	diffs := config.Diff(oldCfg, newCfg)
+	if len(diffs) == 0 {
+		return
+	}
+
+	paths := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		paths = append(paths, diff.Path)
+	}
+
+	rec := &model.Audit{
+		Event:  "configChanged",
+		Actor:  model.AUDIT_ACTOR_SYSTEM,
+		Target: strings.Join(paths, ","),
+	}
+	rec.Success()
+
+	s.FakeApp().LogAuditRec(rec)
+}
+
+// reconfigureAuditLog applies changed audit settings to the audit log file.
+func (s *Server) reconfigureAuditLog(_, newCfg *model.Config) {
+	s.auditLog.Reconfigure(utils.MloggerConfigFromAuditConfig(&newCfg.AuditSettings, utils.GetAuditFileLocation))
+}
+
+// GetAudits returns a page of the audit records matching the query, newest first.
+func (a *App) GetAudits(query *model.AuditQuery) ([]*model.Audit, *model.AppError) {
+	if query.PerPage <= 0 {
+		query.PerPage = model.AUDIT_QUERY_DEFAULT_PER_PAGE
+	} else if query.PerPage > model.AUDIT_QUERY_MAX_PER_PAGE {
+		query.PerPage = model.AUDIT_QUERY_MAX_PER_PAGE
+	}
+
+	if query.Page < 0 {
+		return nil, model.NewInvalidUrlParamError("page")
+	}
+
+	return a.Store().Audit().Query(a.Context(), query)
+}
+
+// pruneAudits removes the audit records older than the retention period.
+func (s *Server) pruneAudits(now time.Time) {
+	retentionDays := *s.Config().AuditSettings.RetentionDays
+	if retentionDays == 0 {
+		return
+	}
+
+	before := now.AddDate(0, 0, -retentionDays)
+	if count := s.Store.Audit().PermanentDeleteBefore(before.UnixNano() / int64(time.Millisecond)); count > 0 {
+		mlog.Info("Removed audit records past retention", mlog.Int("count", count), mlog.Int("retention_days", retentionDays))
+	}
+}
+
+// startAuditPruner starts a background worker that periodically removes audit records older than
+// AuditSettings.RetentionDays.
+func (s *Server) startAuditPruner() {
+	s.auditPrunerStop = make(chan struct{})
+	s.auditPrunerDone = make(chan struct{})
+
+	go func() {
+		defer close(s.auditPrunerDone)
+
+		ticker := time.NewTicker(auditPrunerInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				s.pruneAudits(now)
+			case <-s.auditPrunerStop:
+				return
+			}
+		}
+	}()
+}
+
+// stopAuditPruner stops the worker started by startAuditPruner and waits for it to exit.
+func (s *Server) stopAuditPruner() {
+	if s.auditPrunerStop == nil {
+		return
+	}
+
+	close(s.auditPrunerStop)
+	<-s.auditPrunerDone
+	s.auditPrunerStop = nil
+}
@@ -1,11 +1,16 @@
 package app
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"runtime/debug"
+	"strings"
 
 	"github.com/pkg/errors"
 
+	"github.com/topoface/snippet-challenge/config"
 	"github.com/topoface/snippet-challenge/mlog"
 	"github.com/topoface/snippet-challenge/model"
 )
@@ -62,6 +67,17 @@ func (a *App) AddConfigListener(listener func(*model.Config, *model.Config)) str
 	return a.Srv().AddConfigListener(listener)
 }
 
+// AddConfigListenerForKeys registers a function to be called like with AddConfigListener, but only when
+// any of the settings matched by the keys changed. A key is the path of a setting such as
+// "ServiceSettings.SiteURL", the path of a section followed by ".*" such as "LogSettings.*", or "*".
+func (s *Server) AddConfigListenerForKeys(listener func(*model.Config, *model.Config), keys ...string) string {
+	return s.configStore.AddKeyedListener(listener, keys...)
+}
+
+func (a *App) AddConfigListenerForKeys(listener func(*model.Config, *model.Config), keys ...string) string {
+	return a.Srv().AddConfigListenerForKeys(listener, keys...)
+}
+
 // Removes a listener function by the unique ID returned when AddConfigListener was called
 func (s *Server) RemoveConfigListener(id string) {
 	s.configStore.RemoveListener(id)
@@ -85,9 +101,15 @@ func (a *App) GetConfigFile(name string) ([]byte, error) {
 	return data, nil
 }
 
-// GetSanitizedConfig gets the configuration for a system admin without any secrets.
+// GetSanitizedConfig gets the configuration for a system admin without any secrets. Restricted
+// fields are removed as well while ExperimentalSettings.RestrictSystemAdmin is enabled.
 func (a *App) GetSanitizedConfig() *model.Config {
 	cfg := a.Config().Clone()
+	cfg.Sanitize()
+
+	if *a.Config().ExperimentalSettings.RestrictSystemAdmin {
+		config.RemoveRestrictedFields(cfg)
+	}
 
 	return cfg
 }
@@ -97,12 +119,194 @@ func (a *App) GetEnvironmentConfig() map[string]interface{} {
 	return a.EnvironmentConfig()
 }
 
-// SaveConfig replaces the active configuration, optionally notifying cluster peers.
+// SaveConfig replaces the active configuration, optionally notifying cluster peers. While
+// ExperimentalSettings.RestrictSystemAdmin is enabled, changes to restricted fields are refused.
 func (a *App) SaveConfig(newCfg *model.Config) *model.AppError {
+	if *a.Config().ExperimentalSettings.RestrictSystemAdmin {
+		cfg := newCfg.Clone()
+		cfg.SetDefaults()
+		if changed := config.ChangedRestrictedFields(a.Config(), cfg); len(changed) > 0 {
+			return model.ErrPermissionDenied.NewWithID("SaveConfig", "app.save_config.restricted_fields.app_error", map[string]interface{}{"Fields": strings.Join(changed, ", ")}, "")
+		}
+	}
+
 	_, err := a.Srv().configStore.Set(newCfg)
 	if err != nil {
+		if appErr, ok := errors.Cause(err).(*model.AppError); ok {
+			return appErr
+		}
 		return model.NewAppError("saveConfig", "app.save_config.app_error", nil, err.Error(), http.StatusInternalServerError)
 	}
 
 	return nil
 }
+
+// PatchConfig applies the settings present in the JSON patch on top of the active configuration
+// and saves the result. Secrets sent back as FAKE_SETTING, as they appear in the sanitized
+// config, keep their current value. A setting can't be unset with null.
+func (a *App) PatchConfig(patch []byte) (*model.Config, *model.AppError) {
+	oldCfg := a.Config()
+	newCfg := oldCfg.Clone()
+
+	var settings interface{}
+	if err := json.Unmarshal(patch, &settings); err != nil {
+		return nil, model.ValidationError("PatchConfig", "app.patch_config.invalid_json.app_error", nil, err.Error())
+	}
+	if name := findNullSetting("", settings); name != "" {
+		return nil, model.ValidationError("PatchConfig", "app.patch_config.null_setting.app_error", map[string]interface{}{"Name": name}, "")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patch))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(newCfg); err != nil {
+		return nil, model.ValidationError("PatchConfig", "app.patch_config.invalid_json.app_error", nil, err.Error())
+	}
+
+	if *oldCfg.ExperimentalSettings.RestrictSystemAdmin {
+		config.RestoreRestrictedFields(oldCfg, newCfg)
+	}
+	desanitize(oldCfg, newCfg)
+
+	if err := newCfg.IsValid(); err != nil {
+		return nil, err
+	}
+
+	if err := a.SaveConfig(newCfg); err != nil {
+		return nil, err
+	}
+
+	return a.GetSanitizedConfig(), nil
+}
+
+// findNullSetting returns the path of the first null setting in the decoded JSON value, or an
+// empty string if there is none. Decoding null into a pointer setting would leave it nil.
+func findNullSetting(path string, value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return path
+	case map[string]interface{}:
+		for key, child := range value {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			if name := findNullSetting(childPath, child); name != "" {
+				return name
+			}
+		}
+	case []interface{}:
+		for i, child := range value {
+			if name := findNullSetting(fmt.Sprintf("%s[%d]", path, i), child); name != "" {
+				return name
+			}
+		}
+	}
+
+	return ""
+}
+
+// desanitize restores the secrets of target that still hold the FAKE_SETTING placeholder, as
+// set by model.Config.Sanitize.
+func desanitize(actual, target *model.Config) {
+	if target.ServiceSettings.AdminAccessToken != nil && *target.ServiceSettings.AdminAccessToken == model.FAKE_SETTING {
+		target.ServiceSettings.AdminAccessToken = model.NewString(*actual.ServiceSettings.AdminAccessToken)
+	}
+
+	desanitizeURLPassword(actual.TracingSettings.OTLPEndpoint, target.TracingSettings.OTLPEndpoint)
+
+	// Sinks are matched by position, and a masked address only matches the address it came from.
+	for i := range target.LogSettings.Sinks {
+		if i < len(actual.LogSettings.Sinks) {
+			desanitizeURLPassword(actual.LogSettings.Sinks[i].Address, target.LogSettings.Sinks[i].Address)
+		}
+	}
+}
+
+// desanitizeURLPassword restores the password of target if it is still the masked actual URL.
+func desanitizeURLPassword(actual, target *string) {
+	if actual == nil || target == nil || *target == *actual {
+		return
+	}
+
+	if *target == model.MaskURLPassword(*actual) {
+		*target = *actual
+	}
+}
+
+// configHistoryEntry returns the recorded configuration with the given id.
+func (a *App) configHistoryEntry(id string) (*model.ConfigHistoryEntry, *model.AppError) {
+	if a.Srv().configHistory == nil {
+		return nil, model.ErrUnavailable.NewWithID("getConfigHistoryEntry", "app.config_history.unavailable.app_error", nil, "")
+	}
+
+	entry, err := a.Srv().configHistory.Get(id)
+	if err == config.ErrHistoryEntryNotFound {
+		return nil, model.NotFoundError("getConfigHistoryEntry", "id="+id)
+	} else if err != nil {
+		return nil, model.NewAppError("getConfigHistoryEntry", "app.config_history.get.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return entry, nil
+}
+
+// GetConfigHistory returns the previously active configurations, newest first, without the
+// configurations themselves.
+func (a *App) GetConfigHistory() ([]*model.ConfigHistoryEntry, *model.AppError) {
+	if a.Srv().configHistory == nil {
+		return nil, model.ErrUnavailable.NewWithID("GetConfigHistory", "app.config_history.unavailable.app_error", nil, "")
+	}
+
+	entries, err := a.Srv().configHistory.Entries()
+	if err != nil {
+		return nil, model.NewAppError("GetConfigHistory", "app.config_history.get.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	for _, entry := range entries {
+		entry.Config = nil
+	}
+
+	return entries, nil
+}
+
+// GetConfigHistoryDiff returns the settings changed from one recorded configuration to another,
+// or to the active configuration if toID is empty.
+func (a *App) GetConfigHistoryDiff(fromID, toID string) (model.ConfigDiffs, *model.AppError) {
+	from, err := a.configHistoryEntry(fromID)
+	if err != nil {
+		return nil, err
+	}
+
+	toCfg := a.Srv().configStore.RemoveEnvironmentOverrides(a.Config())
+	if toID != "" {
+		to, err := a.configHistoryEntry(toID)
+		if err != nil {
+			return nil, err
+		}
+		toCfg = to.Config
+	}
+
+	return config.Diff(from.Config, toCfg), nil
+}
+
+// RollbackConfig makes the recorded configuration with the given id the active configuration
+// again, provided it is still valid. The admin access token keeps its current value, as with
+// config.RollbackConfig.
+func (a *App) RollbackConfig(id string) (*model.Config, *model.AppError) {
+	entry, err := a.configHistoryEntry(id)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := config.RollbackConfig(entry, a.Config())
+	if err := cfg.IsValid(); err != nil {
+		return nil, err
+	}
+
+	if err := a.SaveConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	a.Log().Info("Rolled back config", mlog.String("id", id))
+
+	return a.GetSanitizedConfig(), nil
+}
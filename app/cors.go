@@ -0,0 +1,52 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/gorilla/handlers"
+
+	"github.com/topoface/snippet-challenge/mlog"
+	"github.com/topoface/snippet-challenge/model"
+)
+
+// newCORSHandler wraps the given handler with the CORS policy described by the settings.
+func newCORSHandler(settings model.CorsSettings, h http.Handler) http.Handler {
+	options := []handlers.CORSOption{
+		handlers.AllowedOrigins(settings.AllowedOrigins),
+		handlers.AllowedOriginValidator(settings.IsOriginAllowed),
+		handlers.AllowedMethods(settings.AllowedMethods),
+		handlers.AllowedHeaders(settings.AllowedHeaders),
+		handlers.ExposedHeaders(settings.ExposedHeaders),
+		handlers.MaxAge(*settings.MaxAge),
+	}
+
+	if *settings.AllowCredentials {
+		options = append(options, handlers.AllowCredentials())
+	}
+
+	cors := handlers.CORS(options...)(h)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The allowed origin is echoed back, so caches must key responses on it.
+		if r.Header.Get("Origin") != "" {
+			w.Header().Add("Vary", "Origin")
+		}
+		cors.ServeHTTP(w, r)
+	})
+}
+
+// ServeHTTP dispatches to the root router through the CORS policy of the current config.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.corsHandler.Load().(http.Handler).ServeHTTP(w, r)
+}
+
+// updateCORSHandler rebuilds the CORS handler from the CORS settings.
+func (s *Server) updateCORSHandler(_, newCfg *model.Config) {
+	s.corsHandler.Store(newCORSHandler(newCfg.CorsSettings, s.RootRouter))
+
+	mlog.Info("Applied CORS policy",
+		mlog.Any("allowed_origins", newCfg.CorsSettings.AllowedOrigins),
+		mlog.Any("allowed_methods", newCfg.CorsSettings.AllowedMethods),
+		mlog.Bool("allow_credentials", *newCfg.CorsSettings.AllowCredentials),
+	)
+}
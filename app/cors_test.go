@@ -0,0 +1,49 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+func TestCORSHandler(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for name, tc := range map[string]struct {
+		AllowedOrigins   []string
+		AllowCredentials bool
+		Origin           string
+		AllowOrigin      string
+		AllowCredential  string
+	}{
+		"any origin":                    {[]string{"*"}, false, "https://example.com", "*", ""},
+		"exact origin":                  {[]string{"https://example.com"}, false, "https://example.com", "https://example.com", ""},
+		"exact origin with credentials": {[]string{"https://example.com"}, true, "https://example.com", "https://example.com", "true"},
+		"wildcard subdomain":            {[]string{"https://*.example.com"}, true, "https://app.example.com", "https://app.example.com", "true"},
+		"scheme mismatch":               {[]string{"https://*.example.com"}, true, "http://app.example.com", "", ""},
+		"port mismatch":                 {[]string{"https://example.com"}, false, "https://example.com:8443", "", ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			settings := model.CorsSettings{
+				AllowedOrigins:   tc.AllowedOrigins,
+				AllowCredentials: model.NewBool(tc.AllowCredentials),
+			}
+			settings.SetDefaults()
+
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/snippets/name", nil)
+			r.Header.Set("Origin", tc.Origin)
+			w := httptest.NewRecorder()
+			newCORSHandler(settings, ok).ServeHTTP(w, r)
+
+			assert.Equal(t, tc.AllowOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tc.AllowCredential, w.Header().Get("Access-Control-Allow-Credentials"))
+			assert.Contains(t, w.Header().Values("Vary"), "Origin")
+		})
+	}
+}
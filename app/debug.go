@@ -0,0 +1,150 @@
+package app
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/topoface/snippet-challenge/mlog"
+	"github.com/topoface/snippet-challenge/model"
+)
+
+// newDebugHandler serves the net/http/pprof profiles under /debug/pprof/ and the runtime stats of
+// the server under /debug/stats.
+func newDebugHandler(s *Server) http.Handler {
+	router := http.NewServeMux()
+	router.HandleFunc("/debug/pprof/", pprof.Index)
+	router.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	router.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	router.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	router.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	router.HandleFunc("/debug/stats", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write([]byte(s.GetRuntimeStats().ToJSON()))
+	})
+	return router
+}
+
+// DebugHandler returns the handler serving the pprof and runtime stats endpoints. Callers are
+// responsible for only exposing it to developers.
+func (a *App) DebugHandler() http.Handler {
+	return a.Srv().debugHandler
+}
+
+// GetRuntimeStats returns a snapshot of the goroutine, memory, GC and store statistics.
+func (a *App) GetRuntimeStats() *model.RuntimeStats {
+	return a.Srv().GetRuntimeStats()
+}
+
+// GetRuntimeStats returns a snapshot of the goroutine, memory, GC and store statistics.
+func (s *Server) GetRuntimeStats() *model.RuntimeStats {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	stats := &model.RuntimeStats{
+		GoVersion:  runtime.Version(),
+		NumCPU:     runtime.NumCPU(),
+		Goroutines: runtime.NumGoroutine(),
+		Uptime:     int64(time.Since(s.startTime).Seconds()),
+		Memory: model.RuntimeMemStats{
+			Alloc:        memStats.Alloc,
+			TotalAlloc:   memStats.TotalAlloc,
+			Sys:          memStats.Sys,
+			Mallocs:      memStats.Mallocs,
+			Frees:        memStats.Frees,
+			HeapAlloc:    memStats.HeapAlloc,
+			HeapInuse:    memStats.HeapInuse,
+			HeapIdle:     memStats.HeapIdle,
+			HeapReleased: memStats.HeapReleased,
+			HeapObjects:  memStats.HeapObjects,
+			StackInuse:   memStats.StackInuse,
+		},
+		GC: model.RuntimeGCStats{
+			NumGC:        memStats.NumGC,
+			NumForcedGC:  memStats.NumForcedGC,
+			PauseTotalNs: memStats.PauseTotalNs,
+			LastPauseNs:  memStats.PauseNs[(memStats.NumGC+255)%256],
+			NextGC:       memStats.NextGC,
+			CPUFraction:  memStats.GCCPUFraction,
+		},
+	}
+
+	if memStats.LastGC != 0 {
+		stats.GC.LastGC = int64(memStats.LastGC / uint64(time.Millisecond))
+	}
+
+	if s.Store != nil {
+		stats.Store.Snippets, stats.Store.SnippetBytes = s.Store.Snippet().Stats()
+	}
+
+	return stats
+}
+
+// StartDebugServer serves the debug endpoints on ServiceSettings.DebugListenAddress, which is
+// restricted to loopback addresses, when one is configured.
+func (s *Server) StartDebugServer() error {
+	s.debugLock.Lock()
+	defer s.debugLock.Unlock()
+
+	settings := s.Config().ServiceSettings
+	if *settings.DebugListenAddress == "" {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", *settings.DebugListenAddress)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen on %s for debug endpoints", *settings.DebugListenAddress)
+	}
+
+	s.debugServer = &http.Server{
+		Handler:           s.debugHandler,
+		ReadHeaderTimeout: time.Duration(*settings.ReadHeaderTimeout) * time.Second,
+	}
+
+	mlog.Info("Debug server is listening", mlog.String("address", listener.Addr().String()))
+
+	server := s.debugServer
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			mlog.Critical("Error starting debug server", mlog.Err(err))
+		}
+	}()
+
+	return nil
+}
+
+// StopDebugServer stops the server started by StartDebugServer, if any.
+func (s *Server) StopDebugServer() {
+	s.debugLock.Lock()
+	defer s.debugLock.Unlock()
+
+	if s.debugServer == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Profiles may take a while to collect, so abort them rather than wait.
+	if err := s.debugServer.Shutdown(ctx); err != nil {
+		mlog.Warn("Failed to stop debug server cleanly", mlog.Err(err))
+		s.debugServer.Close()
+	}
+	s.debugServer = nil
+
+	mlog.Info("Debug server stopped")
+}
+
+// restartDebugServerOnChange starts, stops or moves the debug server when its address changes.
+func (s *Server) restartDebugServerOnChange(_, _ *model.Config) {
+	s.StopDebugServer()
+	if err := s.StartDebugServer(); err != nil {
+		mlog.Error("Failed to restart debug server after config change", mlog.Err(err))
+	}
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"github.com/topoface/snippet-challenge/mlog"
+	"github.com/topoface/snippet-challenge/model"
+	"github.com/topoface/snippet-challenge/services/filestore"
+	"github.com/topoface/snippet-challenge/services/tracing"
+)
+
+// GetReadinessReport checks every dependency required to serve requests. The server reports
+// itself unready as soon as a shutdown begins, so that load balancers stop routing to it.
+func (a *App) GetReadinessReport() *model.ReadinessReport {
+	ctx, span := tracing.StartSpan(a.Context(), "App.GetReadinessReport")
+	defer span.End()
+
+	report := &model.ReadinessReport{
+		Status:       model.STATUS_OK,
+		Dependencies: map[string]string{},
+	}
+
+	check := func(name string, err *model.AppError) {
+		if err != nil {
+			// The report is served without authentication, so the details only go to the log.
+			a.Log().Error("Readiness check failed", mlog.String("dependency", name), mlog.Err(err))
+			report.Status = model.STATUS_UNHEALTHY
+			report.Dependencies[name] = model.STATUS_UNHEALTHY
+			return
+		}
+		report.Dependencies[name] = model.STATUS_OK
+	}
+
+	if a.Srv().IsShuttingDown() {
+		report.Status = model.STATUS_SHUTTING_DOWN
+	}
+
+	check("store", a.Store().Ping())
+
+	// The last good configuration stays active when a reload fails, so the failure is only seen here.
+	if err := a.Srv().configStore.LoadError(); err != nil {
+		check("config", model.ErrUnavailable.NewWithID("App.GetReadinessReport", "app.health.config_load.app_error", nil, err.Error()))
+	} else {
+		check("config", nil)
+	}
+
+	if backend, err := a.Srv().FileBackend(); err != nil {
+		check("file_backend", err)
+	} else {
+		check("file_backend", filestore.NewTracedFileBackend(ctx, backend).TestConnection())
+	}
+
+	return report
+}
@@ -0,0 +1,140 @@
+package app
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// errListenerClosed is returned by the listeners of a handoffListener once it is closed.
+var errListenerClosed = errors.New("listener closed")
+
+// handoffListener accepts connections on a socket on behalf of the successive http.Servers
+// serving it, so that a server can be replaced without closing the socket and refusing
+// connections in the meantime. Each server gets its own listener from Listener, which it may
+// close without affecting the socket.
+type handoffListener struct {
+	socket  net.Listener
+	address string // The configured address the socket was bound to
+
+	conns     chan net.Conn
+	closed    chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	err       error
+}
+
+func newHandoffListener(address string) (*handoffListener, error) {
+	socket, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &handoffListener{
+		socket:  socket,
+		address: address,
+		conns:   make(chan net.Conn),
+		closed:  make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go l.run()
+
+	return l, nil
+}
+
+// run accepts connections until the socket is closed, handing each of them to whichever server
+// listener asks first.
+func (l *handoffListener) run() {
+	defer close(l.done)
+
+	var delay time.Duration
+	for {
+		conn, err := l.socket.Accept()
+		if err != nil {
+			select {
+			case <-l.closed:
+				l.err = errListenerClosed
+				return
+			default:
+			}
+
+			// Back off on errors such as running out of file descriptors, as http.Server does.
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				if delay == 0 {
+					delay = 5 * time.Millisecond
+				} else if delay *= 2; delay > time.Second {
+					delay = time.Second
+				}
+				time.Sleep(delay)
+				continue
+			}
+
+			l.err = err
+			return
+		}
+		delay = 0
+
+		select {
+		case l.conns <- conn:
+		case <-l.closed:
+			conn.Close()
+		}
+	}
+}
+
+func (l *handoffListener) Addr() net.Addr {
+	return l.socket.Addr()
+}
+
+// Listener returns a listener for a server to accept the connections of the socket with.
+func (l *handoffListener) Listener() net.Listener {
+	return &serverListener{
+		handoff: l,
+		closed:  make(chan struct{}),
+	}
+}
+
+// Close closes the socket. Servers accepting connections from it stop with errListenerClosed.
+func (l *handoffListener) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		close(l.closed)
+		err = l.socket.Close()
+		<-l.done
+	})
+
+	return err
+}
+
+// serverListener is the listener of a server accepting connections from a handoffListener.
+type serverListener struct {
+	handoff   *handoffListener
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (l *serverListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.handoff.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, errListenerClosed
+	case <-l.handoff.done:
+		return nil, l.handoff.err
+	}
+}
+
+// Close stops the server from accepting connections, leaving the socket open for the next one.
+func (l *serverListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+	})
+
+	return nil
+}
+
+func (l *serverListener) Addr() net.Addr {
+	return l.handoff.Addr()
+}
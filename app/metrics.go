@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/topoface/snippet-challenge/mlog"
+	"github.com/topoface/snippet-challenge/model"
+	"github.com/topoface/snippet-challenge/services/metrics"
+)
+
+func (a *App) Metrics() *metrics.Metrics {
+	return a.Srv().Metrics
+}
+
+// StartMetricsServer serves /metrics on MetricsSettings.ListenAddress when metrics are enabled.
+func (s *Server) StartMetricsServer() error {
+	s.metricsLock.Lock()
+	defer s.metricsLock.Unlock()
+
+	settings := s.Config().MetricsSettings
+	if !*settings.Enable {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", *settings.ListenAddress)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen on %s for metrics", *settings.ListenAddress)
+	}
+
+	router := http.NewServeMux()
+	router.Handle("/metrics", s.Metrics.Handler())
+
+	s.metricsServer = &http.Server{
+		Handler:           router,
+		ReadHeaderTimeout: time.Duration(*s.Config().ServiceSettings.ReadHeaderTimeout) * time.Second,
+	}
+
+	mlog.Info("Metrics server is listening", mlog.String("address", listener.Addr().String()))
+
+	server := s.metricsServer
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			mlog.Critical("Error starting metrics server", mlog.Err(err))
+		}
+	}()
+
+	return nil
+}
+
+// StopMetricsServer stops the server started by StartMetricsServer, if any.
+func (s *Server) StopMetricsServer() {
+	s.metricsLock.Lock()
+	defer s.metricsLock.Unlock()
+
+	if s.metricsServer == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := s.metricsServer.Shutdown(ctx); err != nil {
+		mlog.Warn("Failed to stop metrics server cleanly", mlog.Err(err))
+		s.metricsServer.Close()
+	}
+	s.metricsServer = nil
+
+	mlog.Info("Metrics server stopped")
+}
+
+// restartMetricsServerOnChange applies changed metrics settings by restarting the metrics server.
+func (s *Server) restartMetricsServerOnChange(_, _ *model.Config) {
+	s.StopMetricsServer()
+	if err := s.StartMetricsServer(); err != nil {
+		mlog.Error("Failed to restart metrics server after config change", mlog.Err(err))
+	}
+}
@@ -1,13 +1,15 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
 	"path"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 
@@ -15,12 +17,15 @@ import (
 	"github.com/topoface/snippet-challenge/mlog"
 	"github.com/topoface/snippet-challenge/model"
 	"github.com/topoface/snippet-challenge/services/filestore"
+	"github.com/topoface/snippet-challenge/services/metrics"
 	"github.com/topoface/snippet-challenge/store"
 	"github.com/topoface/snippet-challenge/utils"
 )
 
 var MaxNotificationsPerChannelDefault int64 = 1000000
 
+const snippetReaperInterval = 10 * time.Second
+
 type Server struct {
 	Store *store.Store
 
@@ -30,8 +35,34 @@ type Server struct {
 	Server     *http.Server
 	ListenAddr *net.TCPAddr
 	Log        *mlog.Logger
+	Metrics    *metrics.Metrics
+
+	auditLog *mlog.Logger
+
+	configStore   config.Store
+	configHistory *config.History
+
+	metricsServer *http.Server
+	metricsLock   sync.Mutex
+
+	debugHandler http.Handler
+	debugServer  *http.Server
+	debugLock    sync.Mutex
+
+	startTime time.Time
+
+	shuttingDown      int32
+	serverLock        sync.Mutex
+	listener          *handoffListener
+	drainingServers   sync.WaitGroup
+	corsHandler       atomic.Value
+	configListenerIDs []string
+
+	snippetReaperStop chan struct{}
+	snippetReaperDone chan struct{}
 
-	configStore config.Store
+	auditPrunerStop chan struct{}
+	auditPrunerDone chan struct{}
 }
 
 func NewServer(options ...Option) (*Server, error) {
@@ -39,6 +70,7 @@ func NewServer(options ...Option) (*Server, error) {
 
 	s := &Server{
 		RootRouter: rootRouter,
+		startTime:  time.Now(),
 	}
 
 	for _, option := range options {
@@ -47,6 +79,10 @@ func NewServer(options ...Option) (*Server, error) {
 		}
 	}
 
+	if err := utils.TranslationsPreInit(); err != nil {
+		return nil, errors.Wrap(err, "unable to load translations")
+	}
+
 	if s.configStore == nil {
 		configStore, err := config.NewFileStore("config.json", true)
 		if err != nil {
@@ -66,10 +102,36 @@ func NewServer(options ...Option) (*Server, error) {
 	// Use this app logger as the global logger (eventually remove all instances of global logging)
 	mlog.InitGlobalLogger(s.Log)
 
+	// Apply changed log settings without a restart, such as to turn on debug logging.
+	s.AddConfigListenerForKeys(s.reconfigureLogger, "LogSettings.*")
+
+	s.auditLog = NewAuditLogger(&s.Config().AuditSettings)
+	s.AddConfigListenerForKeys(s.reconfigureAuditLog, "AuditSettings.*")
+
+	configHistory, err := config.NewHistory(s.configStore, config.DefaultHistoryMaxEntries)
+	if err != nil {
+		// The server can run without a history, it just cannot roll back.
+		mlog.Error("Failed to start recording config history", mlog.Err(err))
+	}
+	s.configHistory = configHistory
+
 	if s.Store == nil {
 		s.Store = store.NewStore()
 	}
 
+	s.configureTracing(nil, s.Config())
+	s.AddConfigListenerForKeys(s.configureTracing, "TracingSettings.*")
+
+	s.Metrics = metrics.New(s.Store.Snippet().Stats)
+	s.Store.SetMetrics(s.Metrics)
+	s.AddConfigListener(func(oldCfg, newCfg *model.Config) {
+		s.Metrics.IncrementConfigReloads()
+		mlog.Info("Configuration reloaded", mlog.Any("changes", config.Diff(oldCfg, newCfg)))
+	})
+	s.AddConfigListener(s.auditConfigChange)
+
+	s.debugHandler = newDebugHandler(s)
+
 	subpath := "/"
 	s.Router = s.RootRouter.PathPrefix(subpath).Subrouter()
 
@@ -87,56 +149,191 @@ func NewServer(options ...Option) (*Server, error) {
 	return s, nil
 }
 
+// Shutdown stops the server in order: it reports itself as not ready, keeps serving for
+// ServiceSettings.ShutdownDrainPeriod seconds, stops accepting new connections, drains in-flight requests for up to ServiceSettings.ShutdownTimeout seconds,
+// stops the background workers, flushes pending store writes and traces, and finally syncs
+// the logger.
 func (s *Server) Shutdown() error {
 	mlog.Info("Stopping Server...")
 
-	s.configStore.Close()
+	atomic.StoreInt32(&s.shuttingDown, 1)
+	mlog.Info("Reporting server as not ready")
+	s.waitShutdownDrainPeriod()
+
+	for _, id := range s.configListenerIDs {
+		s.RemoveConfigListener(id)
+	}
+	s.configListenerIDs = nil
+
+	shutdownErr := s.stopHTTPServer()
+
+	s.StopMetricsServer()
+	s.StopDebugServer()
+
+	s.stopSnippetReaper()
+	s.stopAuditPruner()
+
+	if s.Store != nil {
+		mlog.Info("Flushing store writes")
+		s.Store.Close()
+	}
+
+	if s.configHistory != nil {
+		s.configHistory.Close()
+	}
+
+	if err := s.configStore.Close(); err != nil {
+		mlog.Error("Failed to close config store", mlog.Err(err))
+	}
+
+	s.stopTracing()
+
+	if err := s.auditLog.Close(); err != nil {
+		mlog.Error("Failed to close audit log", mlog.Err(err))
+	}
 
 	mlog.Info("Server stopped")
-	return nil
+
+	// Syncing stderr fails on some platforms, so this is best effort.
+	s.Log.Sync()
+
+	return shutdownErr
+}
+
+// IsShuttingDown reports whether Shutdown has been called.
+func (s *Server) IsShuttingDown() bool {
+	return atomic.LoadInt32(&s.shuttingDown) == 1
 }
 
 func (s *Server) Start() error {
 	mlog.Info("Starting Server...")
 
-	headersOk := handlers.AllowedHeaders([]string{"x-api-version", "authorization", "content-type", "client-id", "client-secretkey"})
-	originsOk := handlers.AllowedOrigins([]string{"*"})
-	methodsOk := handlers.AllowedMethods([]string{"POST", "GET", "OPTIONS", "PUT", "PATCH", "DELETe"})
+	s.updateCORSHandler(nil, s.Config())
+	s.configListenerIDs = append(s.configListenerIDs, s.AddConfigListenerForKeys(s.updateCORSHandler, "CorsSettings.*"))
+
+	if err := s.startHTTPServer(); err != nil {
+		return err
+	}
+
+	// http.Server must not be modified once serving, so replace it to apply changed settings.
+	s.configListenerIDs = append(s.configListenerIDs, s.AddConfigListenerForKeys(func(_, _ *model.Config) {
+		if err := s.RestartHTTPServer(); err != nil {
+			mlog.Critical("Failed to restart HTTP server after config change", mlog.Err(err))
+		}
+	}, httpServerSettingKeys...))
+
+	if err := s.StartMetricsServer(); err != nil {
+		mlog.Error("Failed to start metrics server", mlog.Err(err))
+	}
+	s.configListenerIDs = append(s.configListenerIDs, s.AddConfigListenerForKeys(s.restartMetricsServerOnChange, "MetricsSettings.*"))
+
+	if err := s.StartDebugServer(); err != nil {
+		mlog.Error("Failed to start debug server", mlog.Err(err))
+	}
+	s.configListenerIDs = append(s.configListenerIDs, s.AddConfigListenerForKeys(s.restartDebugServerOnChange, "ServiceSettings.DebugListenAddress"))
+
+	s.startSnippetReaper()
+	s.startAuditPruner()
+
+	return nil
+}
+
+// RestartHTTPServer replaces the running HTTP server with one built from the current config. The
+// new server takes over the listening socket unless the listen address changed, so that no
+// connection is refused, while the previous server drains its in-flight requests in the
+// background. A request that changed the config therefore still gets its response.
+func (s *Server) RestartHTTPServer() error {
+	mlog.Info("Restarting HTTP server...")
+
+	s.serverLock.Lock()
+	defer s.serverLock.Unlock()
+
+	oldServer, oldListener := s.Server, s.listener
 
-	var handler http.Handler = handlers.CORS(headersOk, originsOk, methodsOk)(s.RootRouter)
+	// The previous server keeps running if the new one cannot start.
+	if err := s.serveHTTP(); err != nil {
+		return err
+	}
+
+	if oldListener != nil && oldListener != s.listener {
+		oldListener.Close()
+	}
+
+	if oldServer != nil {
+		s.drainingServers.Add(1)
+		go func() {
+			defer s.drainingServers.Done()
+
+			if err := drainHTTPServer(oldServer, s.shutdownTimeout()); err != nil {
+				mlog.Warn("Previous HTTP server did not stop cleanly", mlog.Err(err))
+			}
+		}()
+	}
 
+	return nil
+}
+
+func (s *Server) startHTTPServer() error {
+	s.serverLock.Lock()
+	defer s.serverLock.Unlock()
+
+	return s.serveHTTP()
+}
+
+// serveHTTP starts an http.Server from the current config, reusing the listening socket if the
+// listen address did not change. It must be called with serverLock held.
+func (s *Server) serveHTTP() error {
 	// Creating a logger for logging errors from http.Server at error level
 	errStdLog, err := s.Log.StdLogAt(mlog.LevelError, mlog.String("source", "httpserver"))
 	if err != nil {
 		return err
 	}
 
-	s.Server = &http.Server{
-		Handler:  handler,
-		ErrorLog: errStdLog,
-	}
+	settings := s.Config().ServiceSettings
 
-	addr := *s.Config().ServiceSettings.ListenAddress
+	addr := *settings.ListenAddress
 	if addr == "" {
-		if *s.Config().ServiceSettings.ConnectionSecurity == model.CONN_SECURITY_TLS {
+		if *settings.ConnectionSecurity == model.CONN_SECURITY_TLS {
 			addr = ":https"
 		} else {
 			addr = ":http"
 		}
 	}
 
-	listener, err := net.Listen("tcp", addr)
-	if err != nil {
-		errors.Wrapf(err, "api.server.start_server.starting.critical")
-		return err
+	listener := s.listener
+	if listener == nil || listener.address != addr {
+		listener, err = newHandoffListener(addr)
+		if err != nil {
+			return errors.Wrapf(err, "failed to listen on %s", addr)
+		}
 	}
+
+	server := &http.Server{
+		Handler:           s,
+		ReadTimeout:       time.Duration(*settings.ReadTimeout) * time.Second,
+		ReadHeaderTimeout: time.Duration(*settings.ReadHeaderTimeout) * time.Second,
+		WriteTimeout:      time.Duration(*settings.WriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(*settings.IdleTimeout) * time.Second,
+		MaxHeaderBytes:    *settings.MaxHeaderBytes,
+		ErrorLog:          errStdLog,
+	}
+
+	s.Server = server
+	s.listener = listener
 	s.ListenAddr = listener.Addr().(*net.TCPAddr)
 
 	logListeningPort := fmt.Sprintf("Server is listening on %v", listener.Addr().String())
-	mlog.Info(logListeningPort, mlog.String("address", listener.Addr().String()))
+	mlog.Info(logListeningPort,
+		mlog.String("address", listener.Addr().String()),
+		mlog.Duration("read_timeout", server.ReadTimeout),
+		mlog.Duration("read_header_timeout", server.ReadHeaderTimeout),
+		mlog.Duration("write_timeout", server.WriteTimeout),
+		mlog.Duration("idle_timeout", server.IdleTimeout),
+		mlog.Int("max_header_bytes", server.MaxHeaderBytes),
+	)
 
 	go func() {
-		if err := s.Server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		if err := server.Serve(listener.Listener()); err != nil && err != http.ErrServerClosed && err != errListenerClosed {
 			mlog.Critical("Error starting server", mlog.Err(err))
 			time.Sleep(time.Second)
 		}
@@ -145,6 +342,137 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// stopHTTPServer closes the listening socket and drains in-flight requests for up to
+// ServiceSettings.ShutdownTimeout seconds before closing the remaining connections. It also waits
+// for the servers replaced by RestartHTTPServer to finish draining.
+func (s *Server) stopHTTPServer() error {
+	s.serverLock.Lock()
+	defer s.serverLock.Unlock()
+
+	if s.Server == nil {
+		return nil
+	}
+
+	// Refuse new connections right away rather than leave them waiting out the drain.
+	s.listener.Close()
+
+	err := drainHTTPServer(s.Server, s.shutdownTimeout())
+	s.drainingServers.Wait()
+
+	s.Server = nil
+	s.listener = nil
+
+	return err
+}
+
+// waitShutdownDrainPeriod gives load balancers polling the readiness endpoint the time to stop
+// routing requests to a running server before it stops accepting connections.
+func (s *Server) waitShutdownDrainPeriod() {
+	s.serverLock.Lock()
+	running := s.Server != nil
+	s.serverLock.Unlock()
+
+	period := time.Duration(*s.Config().ServiceSettings.ShutdownDrainPeriod) * time.Second
+	if !running || period <= 0 {
+		return
+	}
+
+	mlog.Info("Waiting for load balancers to stop routing requests", mlog.Duration("period", period))
+	time.Sleep(period)
+}
+
+func (s *Server) shutdownTimeout() time.Duration {
+	return time.Duration(*s.Config().ServiceSettings.ShutdownTimeout) * time.Second
+}
+
+// drainHTTPServer stops the server from accepting connections and waits for its in-flight requests
+// to complete, for up to timeout, before closing the remaining connections.
+func drainHTTPServer(server *http.Server, timeout time.Duration) error {
+	mlog.Info("Draining in-flight requests", mlog.Duration("timeout", timeout))
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		mlog.Warn("Failed to drain in-flight requests, closing remaining connections", mlog.Err(err))
+		server.Close()
+		return errors.Wrap(err, "failed to drain in-flight requests")
+	}
+
+	mlog.Info("Drained in-flight requests")
+
+	return nil
+}
+
+// reconfigureLogger applies the log settings to the server logger and the loggers derived from it.
+func (s *Server) reconfigureLogger(_, newCfg *model.Config) {
+	s.Log.Reconfigure(utils.MloggerConfigFromLoggerConfig(&newCfg.LogSettings, utils.GetLogFileLocation))
+
+	mlog.Info("Applied log settings",
+		mlog.String("console_level", *newCfg.LogSettings.ConsoleLevel),
+		mlog.String("file_level", *newCfg.LogSettings.FileLevel),
+	)
+}
+
+// httpServerSettingKeys are the settings baked into the http.Server.
+var httpServerSettingKeys = []string{
+	"ServiceSettings.ListenAddress",
+	"ServiceSettings.ConnectionSecurity",
+	"ServiceSettings.ReadTimeout",
+	"ServiceSettings.ReadHeaderTimeout",
+	"ServiceSettings.WriteTimeout",
+	"ServiceSettings.IdleTimeout",
+	"ServiceSettings.MaxHeaderBytes",
+}
+
+// startSnippetReaper starts a background worker that periodically removes expired snippets.
+func (s *Server) startSnippetReaper() {
+	s.snippetReaperStop = make(chan struct{})
+	s.snippetReaperDone = make(chan struct{})
+
+	go func() {
+		defer close(s.snippetReaperDone)
+
+		ticker := time.NewTicker(snippetReaperInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				s.deleteExpiredSnippets(now)
+			case <-s.snippetReaperStop:
+				return
+			}
+		}
+	}()
+}
+
+// deleteExpiredSnippets removes the snippets that expired before now, along with their attachments.
+func (s *Server) deleteExpiredSnippets(now time.Time) {
+	removed := s.Store.Snippet().DeleteExpired(now)
+	if len(removed) == 0 {
+		return
+	}
+
+	for _, snippet := range removed {
+		s.removeSnippetFiles(snippet)
+	}
+	s.Metrics.AddSnippetsExpired(len(removed))
+	mlog.Debug("Removed expired snippets", mlog.Int("count", len(removed)))
+}
+
+// stopSnippetReaper stops the worker started by startSnippetReaper and waits for it to exit.
+func (s *Server) stopSnippetReaper() {
+	if s.snippetReaperStop == nil {
+		return
+	}
+
+	mlog.Info("Stopping snippet reaper")
+	close(s.snippetReaperStop)
+	<-s.snippetReaperDone
+	s.snippetReaperStop = nil
+}
+
 // A temporary bridge to deal with cases where the code is so tighly coupled that
 // this is easier as a temporary solution
 func (s *Server) FakeApp() *App {
@@ -0,0 +1,318 @@
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/topoface/snippet-challenge/mlog"
+	"github.com/topoface/snippet-challenge/model"
+	"github.com/topoface/snippet-challenge/services/filestore"
+	"github.com/topoface/snippet-challenge/services/tracing"
+)
+
+// CreateSnippet stores a new snippet that expires after the requested number of seconds.
+func (a *App) CreateSnippet(request *model.SnippetRequest) (*model.Snippet, *model.AppError) {
+	ctx, span := tracing.StartSpan(a.Context(), "App.CreateSnippet")
+	defer span.End()
+
+	if err := request.IsValid(); err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+
+	expiresIn := request.ExpiresIn
+	if expiresIn == 0 {
+		expiresIn = model.SNIPPET_DEFAULT_EXPIRES_IN
+	}
+
+	snippet := &model.Snippet{
+		ID:        model.NewID(),
+		URL:       a.GetSnippetURL(request.Name),
+		Name:      request.Name,
+		ExpiresAt: time.Now().Add(time.Duration(expiresIn) * time.Second),
+		Body:      request.Body,
+	}
+
+	expired, err := a.Store().Snippet().Save(ctx, snippet)
+	if err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+
+	// The expired snippet was replaced before the reaper got to it, so its files are removed here.
+	if expired != nil {
+		a.Srv().removeSnippetFiles(expired)
+		if a.Metrics() != nil {
+			a.Metrics().AddSnippetsExpired(1)
+		}
+	}
+
+	if a.Metrics() != nil {
+		a.Metrics().IncrementSnippetsCreated()
+	}
+
+	return snippet, nil
+}
+
+// GetSnippet returns the snippet with the given name, extending its expiry on every read.
+func (a *App) GetSnippet(name string) (*model.Snippet, *model.AppError) {
+	ctx, span := tracing.StartSpan(a.Context(), "App.GetSnippet")
+	defer span.End()
+
+	snippet, err := a.Store().Snippet().Touch(ctx, name, model.SNIPPET_EXPIRY_EXTENSION)
+	if err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+
+	if a.Metrics() != nil {
+		a.Metrics().IncrementSnippetsRead()
+	}
+
+	return snippet, nil
+}
+
+// GetSnippets returns a page of the live snippets, sorted by name, without extending their expiry.
+func (a *App) GetSnippets(query *model.SnippetQuery) ([]*model.Snippet, *model.AppError) {
+	ctx, span := tracing.StartSpan(a.Context(), "App.GetSnippets")
+	defer span.End()
+
+	if query.PerPage <= 0 {
+		query.PerPage = model.SNIPPET_QUERY_DEFAULT_PER_PAGE
+	} else if query.PerPage > model.SNIPPET_QUERY_MAX_PER_PAGE {
+		query.PerPage = model.SNIPPET_QUERY_MAX_PER_PAGE
+	}
+
+	if query.Page < 0 {
+		query.Page = 0
+	}
+
+	snippets, err := a.Store().Snippet().GetPage(ctx, query.Page, query.PerPage)
+	if err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+
+	return snippets, nil
+}
+
+// UpdateSnippet changes the body or the expiry of the snippet with the given name.
+func (a *App) UpdateSnippet(name string, patch *model.SnippetPatch) (*model.Snippet, *model.AppError) {
+	ctx, span := tracing.StartSpan(a.Context(), "App.UpdateSnippet")
+	defer span.End()
+
+	if err := patch.IsValid(); err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+
+	snippet, err := a.Store().Snippet().Patch(ctx, name, patch)
+	if err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+
+	return snippet, nil
+}
+
+// DeleteSnippet removes the snippet with the given name along with its attachments.
+func (a *App) DeleteSnippet(name string) *model.AppError {
+	ctx, span := tracing.StartSpan(a.Context(), "App.DeleteSnippet")
+	defer span.End()
+
+	snippet, err := a.Store().Snippet().Delete(ctx, name)
+	if err != nil {
+		span.SetError(err)
+		return err
+	}
+
+	a.Srv().removeSnippetFiles(snippet)
+
+	return nil
+}
+
+// GetSnippetAttachments returns the attachments of the snippet with the given name.
+func (a *App) GetSnippetAttachments(name string) ([]*model.SnippetAttachment, *model.AppError) {
+	ctx, span := tracing.StartSpan(a.Context(), "App.GetSnippetAttachments")
+	defer span.End()
+
+	snippet, err := a.Store().Snippet().Get(ctx, name)
+	if err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+
+	return snippet.Attachments, nil
+}
+
+// GetSnippetAttachment returns the attachment with the given name of a snippet, and its content.
+func (a *App) GetSnippetAttachment(name, attachmentName string) (*model.SnippetAttachment, []byte, *model.AppError) {
+	ctx, span := tracing.StartSpan(a.Context(), "App.GetSnippetAttachment")
+	defer span.End()
+
+	snippet, err := a.Store().Snippet().Get(ctx, name)
+	if err != nil {
+		span.SetError(err)
+		return nil, nil, err
+	}
+
+	attachment := snippet.GetAttachment(attachmentName)
+	if attachment == nil {
+		err = model.ErrNotFound.NewWithID("App.GetSnippetAttachment", "store.snippet.attachment_not_found.app_error", map[string]interface{}{"Name": attachmentName}, "")
+		span.SetError(err)
+		return nil, nil, err
+	}
+
+	backend, err := a.FileBackend()
+	if err != nil {
+		span.SetError(err)
+		return nil, nil, err
+	}
+
+	data, err := backend.ReadFile(snippetAttachmentPath(snippet, attachment))
+	if err != nil {
+		span.SetError(err)
+		return nil, nil, err
+	}
+
+	return attachment, data, nil
+}
+
+// UploadSnippetAttachment attaches the data to the snippet with the given name, replacing the
+// attachment with the same name if any. The content type is detected from the data if not given.
+func (a *App) UploadSnippetAttachment(name, attachmentName, contentType string, data []byte) (*model.SnippetAttachment, *model.AppError) {
+	ctx, span := tracing.StartSpan(a.Context(), "App.UploadSnippetAttachment")
+	defer span.End()
+
+	if !model.IsValidSnippetAttachmentName(attachmentName) {
+		err := model.NewInvalidUrlParamError("attachment_name")
+		span.SetError(err)
+		return nil, err
+	}
+
+	if maxSize := *a.Config().FileSettings.MaxFileSize; int64(len(data)) > maxSize {
+		err := model.ErrSnippetTooLarge.NewWithID("App.UploadSnippetAttachment", "app.snippet.attachment_too_large.app_error", map[string]interface{}{"MaxSize": maxSize}, "")
+		span.SetError(err)
+		return nil, err
+	}
+
+	snippet, err := a.Store().Snippet().Get(ctx, name)
+	if err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	attachment := &model.SnippetAttachment{
+		ID:          model.NewID(),
+		Name:        attachmentName,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		CreateAt:    model.GetMillis(),
+	}
+
+	backend, err := a.FileBackend()
+	if err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+
+	path := snippetAttachmentPath(snippet, attachment)
+	if _, err = backend.WriteFile(bytes.NewReader(data), attachment.Size, path); err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+
+	replaced, err := a.Store().Snippet().SaveAttachment(ctx, name, snippet.ID, attachment)
+	if err != nil {
+		span.SetError(err)
+		a.Srv().removeSnippetFile(backend, path)
+		return nil, err
+	}
+
+	if replaced != nil {
+		a.Srv().removeSnippetFile(backend, snippetAttachmentPath(snippet, replaced))
+	}
+
+	return attachment, nil
+}
+
+// DeleteSnippetAttachment removes the attachment with the given name from a snippet.
+func (a *App) DeleteSnippetAttachment(name, attachmentName string) *model.AppError {
+	ctx, span := tracing.StartSpan(a.Context(), "App.DeleteSnippetAttachment")
+	defer span.End()
+
+	snippet, err := a.Store().Snippet().DeleteAttachment(ctx, name, attachmentName)
+	if err != nil {
+		span.SetError(err)
+		return err
+	}
+
+	backend, err := a.FileBackend()
+	if err != nil {
+		mlog.Warn("Failed to remove the file of a snippet attachment", mlog.String("snippet_id", snippet.ID), mlog.Err(err))
+		return nil
+	}
+
+	a.Srv().removeSnippetFile(backend, snippetAttachmentPath(snippet, snippet.GetAttachment(attachmentName)))
+
+	return nil
+}
+
+// snippetFilesPath is the directory of the files of a snippet in the file backend.
+func snippetFilesPath(snippet *model.Snippet) string {
+	return "snippets/" + snippet.ID
+}
+
+func snippetAttachmentPath(snippet *model.Snippet, attachment *model.SnippetAttachment) string {
+	return snippetFilesPath(snippet) + "/attachments/" + attachment.ID
+}
+
+// removeSnippetFiles removes the attachments of a deleted or expired snippet. Failures are only
+// logged, as the snippet itself is gone either way.
+func (s *Server) removeSnippetFiles(snippet *model.Snippet) {
+	if len(snippet.Attachments) == 0 {
+		return
+	}
+
+	backend, err := s.FileBackend()
+	if err == nil {
+		err = backend.RemoveDirectory(snippetFilesPath(snippet))
+	}
+	if err != nil {
+		mlog.Warn("Failed to remove the files of a snippet", mlog.String("snippet_id", snippet.ID), mlog.Err(err))
+	}
+}
+
+// removeSnippetFile removes a file of a snippet that is no longer referenced, only logging failures.
+func (s *Server) removeSnippetFile(backend filestore.FileBackend, path string) {
+	if err := backend.RemoveFile(path); err != nil {
+		mlog.Warn("Failed to remove a snippet file", mlog.String("path", path), mlog.Err(err))
+	}
+}
+
+// GetSnippetURL returns the public URL of the snippet with the given name.
+func (a *App) GetSnippetURL(name string) string {
+	return a.getSnippetURL(model.API_URL_SUFFIX_V1, name)
+}
+
+// GetSnippetURLV2 returns the public URL of the snippet with the given name in the v2 API.
+func (a *App) GetSnippetURLV2(name string) string {
+	return a.getSnippetURL(model.API_URL_SUFFIX_V2, name)
+}
+
+func (a *App) getSnippetURL(apiURLSuffix, name string) string {
+	siteURL := a.GetSiteURL()
+	if siteURL == "" {
+		siteURL = os.Getenv("HOST_URL")
+	}
+
+	return strings.TrimSuffix(siteURL, "/") + apiURLSuffix + "/snippets/" + url.PathEscape(name)
+}
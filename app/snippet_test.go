@@ -0,0 +1,94 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/config"
+	"github.com/topoface/snippet-challenge/mlog"
+	"github.com/topoface/snippet-challenge/model"
+)
+
+// setupSnippetServer creates a server storing its files in a temporary directory, which is
+// returned along with it.
+func setupSnippetServer(t *testing.T) (*Server, string) {
+	dataDir, err := ioutil.TempDir("", "apptest")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dataDir)
+	})
+
+	cfg := &model.Config{}
+	cfg.SetDefaults()
+	*cfg.FileSettings.Directory = dataDir
+	*cfg.LogSettings.EnableConsole = false
+	*cfg.LogSettings.EnableFile = false
+	*cfg.AuditSettings.FileEnabled = false
+
+	configStore, err := config.NewMemoryStoreWithOptions(&config.MemoryStoreOptions{InitialConfig: cfg})
+	require.NoError(t, err)
+
+	server, err := NewServer(ConfigStore(configStore), SetLogger(mlog.NewLogger(&mlog.LoggerConfiguration{})))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		server.Shutdown()
+	})
+
+	return server, dataDir
+}
+
+// attachmentFiles returns the files stored for the attachments of every snippet.
+func attachmentFiles(t *testing.T, dataDir string) []string {
+	files, err := filepath.Glob(filepath.Join(dataDir, "snippets", "*", "attachments", "*"))
+	require.NoError(t, err)
+	return files
+}
+
+func TestDeleteExpiredSnippetsRemovesAttachments(t *testing.T) {
+	server, dataDir := setupSnippetServer(t)
+
+	a := server.FakeApp()
+	for _, name := range []string{"short", "long"} {
+		_, appErr := a.CreateSnippet(&model.SnippetRequest{Name: name, Body: "body", ExpiresIn: 60})
+		require.Nil(t, appErr)
+		_, appErr = a.UploadSnippetAttachment(name, "notes.txt", "", []byte("notes"))
+		require.Nil(t, appErr)
+	}
+	_, appErr := a.UpdateSnippet("long", &model.SnippetPatch{ExpiresIn: model.NewUint64(3600)})
+	require.Nil(t, appErr)
+
+	require.Len(t, attachmentFiles(t, dataDir), 2)
+
+	server.deleteExpiredSnippets(time.Now().Add(time.Hour / 2))
+
+	assert.Len(t, attachmentFiles(t, dataDir), 1)
+	_, _, appErr = a.GetSnippetAttachment("long", "notes.txt")
+	assert.Nil(t, appErr, "the attachments of live snippets are kept")
+}
+
+func TestCreateSnippetRemovesAttachmentsOfReplacedSnippet(t *testing.T) {
+	server, dataDir := setupSnippetServer(t)
+	a := server.FakeApp()
+
+	expired, appErr := a.CreateSnippet(&model.SnippetRequest{Name: "recipe", Body: "body", ExpiresIn: 1})
+	require.Nil(t, appErr)
+	_, appErr = a.UploadSnippetAttachment("recipe", "notes.txt", "", []byte("notes"))
+	require.Nil(t, appErr)
+	require.Len(t, attachmentFiles(t, dataDir), 1)
+
+	// Wait for the snippet to expire, which the reaper does not notice within the test.
+	time.Sleep(time.Until(expired.ExpiresAt) + 10*time.Millisecond)
+
+	_, appErr = a.CreateSnippet(&model.SnippetRequest{Name: "recipe", Body: "again", ExpiresIn: 60})
+	require.Nil(t, appErr)
+
+	_, err := os.Stat(filepath.Join(dataDir, "snippets", expired.ID))
+	assert.True(t, os.IsNotExist(err), "the files of the replaced snippet are removed: %v", err)
+	assert.Empty(t, attachmentFiles(t, dataDir))
+}
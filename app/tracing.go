@@ -0,0 +1,58 @@
+package app
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/topoface/snippet-challenge/mlog"
+	"github.com/topoface/snippet-challenge/model"
+	"github.com/topoface/snippet-challenge/services/tracing"
+	"github.com/topoface/snippet-challenge/utils"
+)
+
+// newTracer creates a tracer exporting through the exporter selected by the settings.
+func newTracer(settings *model.TracingSettings) (*tracing.Tracer, error) {
+	var exporter tracing.Exporter
+	switch *settings.Exporter {
+	case model.TRACING_EXPORTER_FILE:
+		var err error
+		exporter, err = tracing.NewFileExporter(utils.GetTracesFileLocation(*settings.FileLocation))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create file trace exporter")
+		}
+	case model.TRACING_EXPORTER_OTLP:
+		exporter = tracing.NewOTLPExporter(*settings.OTLPEndpoint, *settings.ServiceName)
+	default:
+		exporter = tracing.NewStdoutExporter()
+	}
+
+	return tracing.NewTracer(exporter), nil
+}
+
+// configureTracing installs or removes the global tracer according to the tracing settings.
+func (s *Server) configureTracing(_, newCfg *model.Config) {
+	var tracer *tracing.Tracer
+	if *newCfg.TracingSettings.Enable {
+		var err error
+		if tracer, err = newTracer(&newCfg.TracingSettings); err != nil {
+			mlog.Error("Failed to configure tracing", mlog.Err(err))
+			return
+		}
+		mlog.Info("Tracing enabled", mlog.String("exporter", *newCfg.TracingSettings.Exporter))
+	}
+
+	if previous := tracing.SetTracer(tracer); previous != nil {
+		if err := previous.Shutdown(); err != nil {
+			mlog.Warn("Failed to shut down previous tracer", mlog.Err(err))
+		}
+	}
+}
+
+// stopTracing flushes the spans queued for export and disables tracing.
+func (s *Server) stopTracing() {
+	if tracer := tracing.SetTracer(nil); tracer != nil {
+		mlog.Info("Flushing traces")
+		if err := tracer.Shutdown(); err != nil {
+			mlog.Warn("Failed to flush traces", mlog.Err(err))
+		}
+	}
+}
@@ -0,0 +1,429 @@
+// Package client is a Go client of the snippet API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+const (
+	DEFAULT_MIN_BACKOFF = 100 * time.Millisecond
+	DEFAULT_MAX_BACKOFF = 5 * time.Second
+
+	jsonContentType = "application/json"
+)
+
+// Client calls the snippet API of a server. Failed requests return a *model.AppError decoded from
+// the response, which unwraps to its catalog entry, so that callers can branch on it with
+// errors.Is, such as errors.Is(err, model.ErrSnippetNotFound).
+type Client struct {
+	URL        string // The site URL of the server
+	APIURL     string // The URL of the versioned API, such as http://localhost:13000/api/v1
+	HTTPClient *http.Client
+	AuthToken  string
+
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// Option configures a Client
+type Option func(c *Client)
+
+// WithHTTPClient makes the client send its requests with the given http.Client.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.HTTPClient = httpClient
+	}
+}
+
+// WithAuthToken makes the client send the token as a bearer token with every request.
+func WithAuthToken(token string) Option {
+	return func(c *Client) {
+		c.AuthToken = token
+	}
+}
+
+// WithRetries makes the client retry a request up to maxRetries times while the server is
+// unavailable or rate limiting, waiting an exponentially growing backoff between minBackoff and
+// maxBackoff, or as long as the server asks with Retry-After. Requests that are safe to repeat
+// are also retried after network errors.
+func WithRetries(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.minBackoff = minBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// New creates a client of the server at the given site URL. It does not retry failed requests
+// unless configured WithRetries.
+func New(siteURL string, options ...Option) *Client {
+	siteURL = strings.TrimSuffix(siteURL, "/")
+	c := &Client{
+		URL:        siteURL,
+		APIURL:     siteURL + model.API_URL_SUFFIX_V1,
+		HTTPClient: &http.Client{},
+		minBackoff: DEFAULT_MIN_BACKOFF,
+		maxBackoff: DEFAULT_MAX_BACKOFF,
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+func (c *Client) snippetsRoute() string {
+	return "/snippets"
+}
+
+func (c *Client) snippetRoute(name string) string {
+	return c.snippetsRoute() + "/" + url.PathEscape(name)
+}
+
+func (c *Client) snippetAttachmentsRoute(name string) string {
+	return c.snippetRoute(name) + "/attachments"
+}
+
+func (c *Client) snippetAttachmentRoute(name, attachmentName string) string {
+	return c.snippetAttachmentsRoute(name) + "/" + url.PathEscape(attachmentName)
+}
+
+// CreateSnippet creates a snippet
+func (c *Client) CreateSnippet(ctx context.Context, request *model.SnippetRequest) (*model.Snippet, error) {
+	body, _ := json.Marshal(request)
+	resp, err := c.doAPIRequest(ctx, http.MethodPost, c.snippetsRoute(), jsonContentType, body)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	return snippetFromResponse(resp)
+}
+
+// GetSnippet gets the snippet with the given name, which extends its expiry.
+func (c *Client) GetSnippet(ctx context.Context, name string) (*model.Snippet, error) {
+	resp, err := c.doAPIRequest(ctx, http.MethodGet, c.snippetRoute(name), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	return snippetFromResponse(resp)
+}
+
+// GetSnippets gets a page of the live snippets, sorted by name. A perPage of 0 gets the default
+// page size of the server.
+func (c *Client) GetSnippets(ctx context.Context, page, perPage int) ([]*model.Snippet, error) {
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(page))
+	if perPage > 0 {
+		query.Set("per_page", strconv.Itoa(perPage))
+	}
+
+	resp, err := c.doAPIRequest(ctx, http.MethodGet, c.snippetsRoute()+"?"+query.Encode(), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	var snippets []*model.Snippet
+	if err := decodeResponse(resp, "GetSnippets", &snippets); err != nil {
+		return nil, err
+	}
+	return snippets, nil
+}
+
+// UpdateSnippet changes the body or the expiry of the snippet with the given name.
+func (c *Client) UpdateSnippet(ctx context.Context, name string, patch *model.SnippetPatch) (*model.Snippet, error) {
+	resp, err := c.doAPIRequest(ctx, http.MethodPut, c.snippetRoute(name), jsonContentType, []byte(patch.ToJSON()))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	return snippetFromResponse(resp)
+}
+
+// DeleteSnippet deletes the snippet with the given name along with its attachments.
+func (c *Client) DeleteSnippet(ctx context.Context, name string) error {
+	resp, err := c.doAPIRequest(ctx, http.MethodDelete, c.snippetRoute(name), "", nil)
+	if err != nil {
+		return err
+	}
+	closeBody(resp)
+
+	return nil
+}
+
+// GetSnippetRaw gets the body of the snippet with the given name, which extends its expiry.
+func (c *Client) GetSnippetRaw(ctx context.Context, name string) ([]byte, error) {
+	resp, err := c.doAPIRequest(ctx, http.MethodGet, c.snippetRoute(name)+"/raw", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	return readResponse(resp, "GetSnippetRaw")
+}
+
+// GetSnippetAttachments gets the attachments of the snippet with the given name.
+func (c *Client) GetSnippetAttachments(ctx context.Context, name string) ([]*model.SnippetAttachment, error) {
+	resp, err := c.doAPIRequest(ctx, http.MethodGet, c.snippetAttachmentsRoute(name), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	var attachments []*model.SnippetAttachment
+	if err := decodeResponse(resp, "GetSnippetAttachments", &attachments); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// UploadSnippetAttachment attaches the data to the snippet with the given name, replacing the
+// attachment with the same name if any. The server detects the content type if it is empty.
+func (c *Client) UploadSnippetAttachment(ctx context.Context, name, attachmentName, contentType string, data []byte) (*model.SnippetAttachment, error) {
+	if data == nil {
+		data = []byte{}
+	}
+
+	resp, err := c.doAPIRequest(ctx, http.MethodPut, c.snippetAttachmentRoute(name, attachmentName), contentType, data)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	var attachment *model.SnippetAttachment
+	if err := decodeResponse(resp, "UploadSnippetAttachment", &attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// GetSnippetAttachment gets the content of an attachment of the snippet with the given name,
+// along with its content type.
+func (c *Client) GetSnippetAttachment(ctx context.Context, name, attachmentName string) ([]byte, string, error) {
+	resp, err := c.doAPIRequest(ctx, http.MethodGet, c.snippetAttachmentRoute(name, attachmentName), "", nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer closeBody(resp)
+
+	data, err := readResponse(resp, "GetSnippetAttachment")
+	if err != nil {
+		return nil, "", err
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// DeleteSnippetAttachment deletes an attachment of the snippet with the given name.
+func (c *Client) DeleteSnippetAttachment(ctx context.Context, name, attachmentName string) error {
+	resp, err := c.doAPIRequest(ctx, http.MethodDelete, c.snippetAttachmentRoute(name, attachmentName), "", nil)
+	if err != nil {
+		return err
+	}
+	closeBody(resp)
+
+	return nil
+}
+
+func snippetFromResponse(resp *http.Response) (*model.Snippet, error) {
+	var snippet *model.Snippet
+	if err := decodeResponse(resp, "snippetFromResponse", &snippet); err != nil {
+		return nil, err
+	}
+	return snippet, nil
+}
+
+// decodeResponse decodes the JSON body of a successful response into v. A body that cannot be
+// decoded is an internal error of the server, with the decoding error in the details.
+func decodeResponse(resp *http.Response, where string, v interface{}) error {
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return model.NewAppError(where, "model.utils.decode_json.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	return nil
+}
+
+// readResponse reads the body of a successful response, reporting a failure to read it like
+// decodeResponse.
+func readResponse(resp *http.Response, where string) ([]byte, error) {
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, model.NewAppError(where, "model.utils.decode_json.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	return data, nil
+}
+
+// doAPIRequest sends a request to the given route of the versioned API, with a body of the given
+// content type if any, retrying it as configured. Responses with an error status are returned as
+// a *model.AppError.
+func (c *Client) doAPIRequest(ctx context.Context, method, route, contentType string, body []byte) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.doRequest(ctx, method, c.APIURL+route, contentType, body)
+		if err != nil {
+			if attempt < c.maxRetries && isIdempotent(method) && ctx.Err() == nil {
+				if sleepErr := c.sleep(ctx, attempt, nil); sleepErr != nil {
+					return nil, err
+				}
+				continue
+			}
+			return nil, err
+		}
+
+		if resp.StatusCode < http.StatusBadRequest {
+			return resp, nil
+		}
+
+		// A request refused as the server was unavailable or rate limiting was never handled, so
+		// it is safe to send again whatever its method.
+		if attempt < c.maxRetries && isRetryableStatus(resp.StatusCode) {
+			closeBody(resp)
+			if err := c.sleep(ctx, attempt, resp); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		appErr := appErrorFromResponse(resp)
+		closeBody(resp)
+		return nil, appErr
+	}
+}
+
+func (c *Client) doRequest(ctx context.Context, method, requestURL, contentType string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, requestURL, reader)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	req.Header.Set("Accept", jsonContentType)
+	if body != nil && contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.AuthToken != "" {
+		req.Header.Set(model.HEADER_AUTH, model.HEADER_BEARER+" "+c.AuthToken)
+	}
+
+	return c.HTTPClient.Do(req)
+}
+
+// sleep waits before retrying the request for the given attempt, as long as the response asks with
+// Retry-After, or else for an exponential backoff with jitter.
+func (c *Client) sleep(ctx context.Context, attempt int, resp *http.Response) error {
+	wait := c.backoff(attempt)
+	if resp != nil {
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			wait = retryAfter
+		}
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of seconds or an HTTP
+// date, into how long to wait from now. A date in the past means retrying right away.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if wait := date.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	backoff := c.minBackoff
+	for i := 0; i < attempt && backoff < c.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > c.maxBackoff {
+		backoff = c.maxBackoff
+	}
+
+	// Spread out the retries of clients that failed at the same time.
+	if half := int64(backoff / 2); half > 0 {
+		backoff = time.Duration(half + rand.Int63n(half+1))
+	}
+	return backoff
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// appErrorFromResponse decodes the AppError written by the server for a failed request, in either
+// of its formats.
+func appErrorFromResponse(resp *http.Response) *model.AppError {
+	body, _ := ioutil.ReadAll(resp.Body)
+	if !json.Valid(body) {
+		return model.NewAppError("appErrorFromResponse", http.StatusText(resp.StatusCode), nil, string(body), resp.StatusCode)
+	}
+
+	var appErr *model.AppError
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == model.PROBLEM_CONTENT_TYPE {
+		appErr = model.AppErrorFromProblemJSON(bytes.NewReader(body))
+	} else {
+		appErr = model.AppErrorFromJSON(bytes.NewReader(body))
+	}
+	appErr.StatusCode = resp.StatusCode
+	return appErr
+}
+
+func closeBody(resp *http.Response) {
+	if resp.Body != nil {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
@@ -0,0 +1,298 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/api"
+	"github.com/topoface/snippet-challenge/app"
+	"github.com/topoface/snippet-challenge/config"
+	"github.com/topoface/snippet-challenge/mlog"
+	"github.com/topoface/snippet-challenge/model"
+)
+
+// setupServer starts an httptest server serving the API as registered by api.Init. The handler
+// wrapping the router, if any, can intercept requests before they reach the API.
+func setupServer(t *testing.T, wrap func(http.Handler) http.Handler) (*app.Server, *httptest.Server) {
+	dataDir, err := ioutil.TempDir("", "clienttest")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dataDir)
+	})
+
+	configStore, err := config.NewMemoryStore()
+	require.NoError(t, err)
+
+	cfg := configStore.Get()
+	*cfg.FileSettings.Directory = dataDir
+	*cfg.LogSettings.EnableConsole = false
+	*cfg.LogSettings.EnableFile = false
+	*cfg.AuditSettings.FileEnabled = false
+	_, err = configStore.Set(cfg)
+	require.NoError(t, err)
+
+	logger := mlog.NewLogger(&mlog.LoggerConfiguration{})
+	server, err := app.NewServer(app.ConfigStore(configStore), app.SetLogger(logger))
+	require.NoError(t, err)
+
+	api.Init(server, server.AppOptions, server.Router)
+
+	var handler http.Handler = server.RootRouter
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+	ts := httptest.NewServer(handler)
+
+	t.Cleanup(func() {
+		ts.Close()
+		server.Shutdown()
+	})
+
+	return server, ts
+}
+
+func TestClient(t *testing.T) {
+	_, ts := setupServer(t, nil)
+	client := New(ts.URL)
+	ctx := context.Background()
+
+	created, err := client.CreateSnippet(ctx, &model.SnippetRequest{Name: "recipe", Body: "1 cup flour", ExpiresIn: 60})
+	require.NoError(t, err)
+	assert.Equal(t, "recipe", created.Name)
+	assert.Equal(t, "1 cup flour", created.Body)
+
+	snippet, err := client.GetSnippet(ctx, "recipe")
+	require.NoError(t, err)
+	assert.Equal(t, "1 cup flour", snippet.Body)
+	assert.True(t, snippet.ExpiresAt.After(created.ExpiresAt), "reading extends the expiry")
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := client.CreateSnippet(ctx, &model.SnippetRequest{Name: "recipe", Body: "again"})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, model.ErrSnippetNameConflict))
+
+		var appErr *model.AppError
+		require.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusConflict, appErr.StatusCode)
+		assert.Equal(t, "A snippet named recipe already exists.", appErr.Message)
+		assert.NotEmpty(t, appErr.RequestID)
+
+		_, err = client.GetSnippet(ctx, "missing")
+		assert.True(t, errors.Is(err, model.ErrSnippetNotFound))
+
+		_, err = client.CreateSnippet(ctx, &model.SnippetRequest{Name: "empty"})
+		assert.True(t, errors.Is(err, model.ErrValidation))
+	})
+}
+
+func TestClientSnippets(t *testing.T) {
+	_, ts := setupServer(t, nil)
+	client := New(ts.URL)
+	ctx := context.Background()
+
+	for _, name := range []string{"b", "a", "c"} {
+		_, err := client.CreateSnippet(ctx, &model.SnippetRequest{Name: name, Body: "body of " + name, ExpiresIn: 60})
+		require.NoError(t, err)
+	}
+
+	t.Run("list", func(t *testing.T) {
+		snippets, err := client.GetSnippets(ctx, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, snippets, 3)
+		assert.Equal(t, "a", snippets[0].Name)
+		assert.Equal(t, "body of a", snippets[0].Body)
+
+		snippets, err = client.GetSnippets(ctx, 1, 2)
+		require.NoError(t, err)
+		require.Len(t, snippets, 1)
+		assert.Equal(t, "c", snippets[0].Name)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		snippet, err := client.UpdateSnippet(ctx, "a", &model.SnippetPatch{Body: model.NewString("changed")})
+		require.NoError(t, err)
+		assert.Equal(t, "changed", snippet.Body)
+
+		_, err = client.UpdateSnippet(ctx, "a", &model.SnippetPatch{Body: model.NewString("")})
+		assert.True(t, errors.Is(err, model.ErrValidation))
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		body, err := client.GetSnippetRaw(ctx, "a")
+		require.NoError(t, err)
+		assert.Equal(t, "changed", string(body))
+
+		_, err = client.GetSnippetRaw(ctx, "missing")
+		assert.True(t, errors.Is(err, model.ErrSnippetNotFound))
+	})
+
+	t.Run("attachments", func(t *testing.T) {
+		attachment, err := client.UploadSnippetAttachment(ctx, "b", "notes.md", "text/markdown", []byte("# notes"))
+		require.NoError(t, err)
+		assert.Equal(t, "notes.md", attachment.Name)
+		assert.EqualValues(t, 7, attachment.Size)
+
+		attachments, err := client.GetSnippetAttachments(ctx, "b")
+		require.NoError(t, err)
+		assert.Equal(t, []*model.SnippetAttachment{attachment}, attachments)
+
+		data, contentType, err := client.GetSnippetAttachment(ctx, "b", "notes.md")
+		require.NoError(t, err)
+		assert.Equal(t, "# notes", string(data))
+		assert.Equal(t, "text/markdown", contentType)
+
+		require.NoError(t, client.DeleteSnippetAttachment(ctx, "b", "notes.md"))
+		_, _, err = client.GetSnippetAttachment(ctx, "b", "notes.md")
+		assert.True(t, errors.Is(err, model.ErrNotFound))
+		assert.True(t, errors.Is(client.DeleteSnippetAttachment(ctx, "b", "notes.md"), model.ErrNotFound))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, client.DeleteSnippet(ctx, "c"))
+
+		_, err := client.GetSnippet(ctx, "c")
+		assert.True(t, errors.Is(err, model.ErrSnippetNotFound))
+		assert.True(t, errors.Is(client.DeleteSnippet(ctx, "c"), model.ErrSnippetNotFound))
+	})
+}
+
+func TestClientUndecodableResponse(t *testing.T) {
+	_, ts := setupServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("<html>not the API</html>"))
+		})
+	})
+
+	_, err := New(ts.URL).GetSnippet(context.Background(), "recipe")
+	require.Error(t, err)
+
+	var appErr *model.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, http.StatusInternalServerError, appErr.StatusCode)
+	assert.Contains(t, appErr.DetailedError, "invalid character")
+}
+
+func TestClientProblemErrors(t *testing.T) {
+	server, ts := setupServer(t, nil)
+	cfg := server.Config().Clone()
+	*cfg.ServiceSettings.ErrorResponseFormat = model.ERROR_RESPONSE_FORMAT_PROBLEM
+	server.UpdateConfig(func(c *model.Config) { *c = *cfg })
+
+	_, err := New(ts.URL).GetSnippet(context.Background(), "missing")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, model.ErrSnippetNotFound))
+	assert.Equal(t, "No snippet named missing was found.", err.(*model.AppError).Message)
+}
+
+func TestClientRetries(t *testing.T) {
+	var failures, requests int32
+	_, ts := setupServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			if atomic.AddInt32(&failures, -1) >= 0 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	ctx := context.Background()
+
+	atomic.StoreInt32(&failures, 2)
+	client := New(ts.URL, WithRetries(2, time.Millisecond, 10*time.Millisecond))
+	_, err := client.CreateSnippet(ctx, &model.SnippetRequest{Name: "retried", Body: "body"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, atomic.LoadInt32(&requests))
+
+	atomic.StoreInt32(&requests, 0)
+	atomic.StoreInt32(&failures, 3)
+	_, err = client.GetSnippet(ctx, "retried")
+	require.Error(t, err)
+	assert.EqualValues(t, 3, atomic.LoadInt32(&requests), "gives up after the configured retries")
+
+	var appErr *model.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, http.StatusServiceUnavailable, appErr.StatusCode)
+}
+
+func TestClientRetryAfterDate(t *testing.T) {
+	var requests int32
+	_, ts := setupServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&requests, 1) == 1 {
+				w.Header().Set("Retry-After", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	// The backoff would outlast the context, so the retry has to follow the date in the past.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := New(ts.URL, WithRetries(1, time.Minute, time.Minute))
+	_, err := client.CreateSnippet(ctx, &model.SnippetRequest{Name: "retried", Body: "body"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&requests))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		Value string
+		Wait  time.Duration
+		OK    bool
+	}{
+		"missing":       {"", 0, false},
+		"seconds":       {"120", 2 * time.Minute, true},
+		"zero":          {"0", 0, true},
+		"negative":      {"-1", 0, false},
+		"date":          {"Mon, 19 Oct 2026 12:00:30 GMT", 30 * time.Second, true},
+		"date in past":  {"Mon, 19 Oct 2026 11:00:00 GMT", 0, true},
+		"rfc 850 date":  {"Monday, 19-Oct-26 12:01:00 GMT", time.Minute, true},
+		"asctime date":  {"Mon Oct 19 12:00:05 2026", 5 * time.Second, true},
+		"invalid":       {"soon", 0, false},
+		"invalid float": {"1.5", 0, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			wait, ok := parseRetryAfter(tc.Value, now)
+			assert.Equal(t, tc.OK, ok)
+			assert.Equal(t, tc.Wait, wait)
+		})
+	}
+}
+
+type recordingTransport struct {
+	requests []*http.Request
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests = append(t.requests, req)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClientOptions(t *testing.T) {
+	_, ts := setupServer(t, nil)
+	transport := &recordingTransport{}
+
+	client := New(ts.URL+"/", WithHTTPClient(&http.Client{Transport: transport}), WithAuthToken("token"))
+	_, err := client.CreateSnippet(context.Background(), &model.SnippetRequest{Name: "authed", Body: "body"})
+	require.NoError(t, err)
+
+	require.Len(t, transport.requests, 1)
+	req := transport.requests[0]
+	assert.Equal(t, model.API_URL_SUFFIX_V1+"/snippets", req.URL.Path)
+	assert.Equal(t, "BEARER token", req.Header.Get(model.HEADER_AUTH))
+}
@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/topoface/snippet-challenge/app"
+	"github.com/topoface/snippet-challenge/config"
+	"github.com/topoface/snippet-challenge/model"
+	"github.com/topoface/snippet-challenge/viper"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Configuration",
+}
+
+var configHistoryCmd = &cobra.Command{
+	Use:     "history",
+	Short:   "List the configuration history",
+	Long:    "List the previously active configurations, newest first, with the settings each of them changed.",
+	Example: "config history",
+	Args:    cobra.NoArgs,
+	RunE:    configHistoryCmdF,
+}
+
+var configDiffCmd = &cobra.Command{
+	Use:     "diff <from> [to]",
+	Short:   "Diff two configurations from the history",
+	Long:    "Show the settings changed from one configuration of the history to another, or to the active configuration if only one is given.",
+	Example: "config diff 4kqopfs8xtb9jnbf4tw3yqhxqa",
+	Args:    cobra.RangeArgs(1, 2),
+	RunE:    configDiffCmdF,
+}
+
+var configRollbackCmd = &cobra.Command{
+	Use:     "rollback <id>",
+	Short:   "Roll back to a configuration from the history",
+	Long:    "Make a configuration from the history the active configuration again, after checking that it is valid.",
+	Example: "config rollback 4kqopfs8xtb9jnbf4tw3yqhxqa",
+	Args:    cobra.ExactArgs(1),
+	RunE:    configRollbackCmdF,
+}
+
+func init() {
+	configCmd.AddCommand(
+		configHistoryCmd,
+		configDiffCmd,
+		configRollbackCmd,
+	)
+	RootCmd.AddCommand(configCmd)
+}
+
+// openConfigHistory opens the configuration store selected by the --config flag along with its history.
+func openConfigHistory() (config.Store, *config.History, error) {
+	configStore, err := config.NewStore(viper.GetString("config"), false)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to load configuration")
+	}
+
+	history, err := config.NewHistory(configStore, config.DefaultHistoryMaxEntries)
+	if err != nil {
+		configStore.Close()
+		return nil, nil, errors.Wrap(err, "failed to load configuration history")
+	}
+
+	return configStore, history, nil
+}
+
+func printConfigDiffs(command *cobra.Command, diffs model.ConfigDiffs) {
+	for _, diff := range diffs {
+		command.Printf("    %s: %v -> %v\n", diff.Path, formatConfigValue(diff.OldValue), formatConfigValue(diff.NewValue))
+	}
+}
+
+func formatConfigValue(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func configHistoryCmdF(command *cobra.Command, args []string) error {
+	configStore, history, err := openConfigHistory()
+	if err != nil {
+		return err
+	}
+	defer configStore.Close()
+	defer history.Close()
+
+	entries, err := history.Entries()
+	if err != nil {
+		return err
+	}
+
+	for i, entry := range entries {
+		active := ""
+		if i == 0 {
+			active = " (active)"
+		}
+
+		createAt := time.Unix(0, entry.CreateAt*int64(time.Millisecond)).Format(time.RFC3339)
+		command.Printf("%s  %s%s\n", entry.ID, createAt, active)
+		if len(entry.Changes) == 0 {
+			command.Println("    no recorded changes")
+		}
+		printConfigDiffs(command, entry.Changes)
+	}
+
+	return nil
+}
+
+func configDiffCmdF(command *cobra.Command, args []string) error {
+	configStore, history, err := openConfigHistory()
+	if err != nil {
+		return err
+	}
+	defer configStore.Close()
+	defer history.Close()
+
+	from, err := history.Get(args[0])
+	if err != nil {
+		return errors.Wrapf(err, "failed to get %s", args[0])
+	}
+
+	toCfg := configStore.RemoveEnvironmentOverrides(configStore.Get())
+	if len(args) > 1 {
+		to, err := history.Get(args[1])
+		if err != nil {
+			return errors.Wrapf(err, "failed to get %s", args[1])
+		}
+		toCfg = to.Config
+	}
+
+	diffs := config.Diff(from.Config, toCfg)
+	if len(diffs) == 0 {
+		command.Println("The configurations are identical.")
+		return nil
+	}
+
+	printConfigDiffs(command, diffs)
+
+	return nil
+}
+
+// logAuditRec appends the audit record of a command to the audit log file, marking it failed with
+// err if the command failed.
+func logAuditRec(configStore config.Store, rec *model.Audit, err error) {
+	settings := configStore.Get().AuditSettings
+	if !*settings.Enable {
+		return
+	}
+
+	if err != nil {
+		rec.Fail(nil)
+		rec.Error = err.Error()
+	}
+
+	logger := app.NewAuditLogger(&settings)
+	defer logger.Close()
+
+	app.WriteAuditRec(logger, rec)
+}
+
+func configRollbackCmdF(command *cobra.Command, args []string) (err error) {
+	configStore, history, err := openConfigHistory()
+	if err != nil {
+		return err
+	}
+	defer configStore.Close()
+	defer history.Close()
+
+	auditRec := &model.Audit{
+		Event:  "rollbackConfig",
+		Actor:  model.AUDIT_ACTOR_CLI,
+		Target: args[0],
+		Status: model.AUDIT_STATUS_FAIL,
+	}
+	defer func() {
+		logAuditRec(configStore, auditRec, err)
+	}()
+
+	entry, err := history.Get(args[0])
+	if err != nil {
+		return errors.Wrapf(err, "failed to get %s", args[0])
+	}
+
+	current := configStore.RemoveEnvironmentOverrides(configStore.Get())
+	cfg := config.RollbackConfig(entry, current)
+	if appErr := cfg.IsValid(); appErr != nil {
+		return errors.Wrapf(appErr, "configuration %s is not valid", entry.ID)
+	}
+
+	diffs := config.Diff(current, cfg)
+
+	if _, err := configStore.Set(cfg); err != nil {
+		return errors.Wrap(err, "failed to roll back configuration")
+	}
+
+	auditRec.Success()
+
+	if len(diffs) == 0 {
+		command.Printf("Configuration %s was already active.\n", entry.ID)
+		return nil
+	}
+
+	command.Printf("Rolled back to configuration %s, changing %s:\n", entry.ID, pluralizeSettings(len(diffs)))
+	printConfigDiffs(command, diffs)
+
+	return nil
+}
+
+func pluralizeSettings(count int) string {
+	if count == 1 {
+		return "1 setting"
+	}
+	return fmt.Sprintf("%d settings", count)
+}
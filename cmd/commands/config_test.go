@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/config"
+	"github.com/topoface/snippet-challenge/model"
+	"github.com/topoface/snippet-challenge/viper"
+)
+
+// setupConfigFile writes the default config, modified by updateConfig, to a temporary file that
+// the commands use as their --config.
+func setupConfigFile(t *testing.T, updateConfig func(*model.Config)) string {
+	dir, err := ioutil.TempDir("", "commandstest")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	cfg := &model.Config{}
+	cfg.SetDefaults()
+	*cfg.AuditSettings.FileLocation = dir
+	updateConfig(cfg)
+
+	path := filepath.Join(dir, "config.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(cfg.ToJSON()), 0600))
+
+	viper.Set("config", path)
+	t.Cleanup(func() {
+		viper.Set("config", "")
+	})
+
+	return path
+}
+
+func TestConfigRollbackKeepsAdminAccessToken(t *testing.T) {
+	path := setupConfigFile(t, func(cfg *model.Config) {
+		*cfg.ServiceSettings.AdminAccessToken = "revoked-token"
+		*cfg.ServiceSettings.SiteURL = "https://old.example.com"
+	})
+
+	configStore, history, err := openConfigHistory()
+	require.NoError(t, err)
+	entries, err := history.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	oldID := entries[0].ID
+
+	cfg := configStore.Get().Clone()
+	*cfg.ServiceSettings.AdminAccessToken = "current-token"
+	*cfg.ServiceSettings.SiteURL = "https://new.example.com"
+	_, err = configStore.Set(cfg)
+	require.NoError(t, err)
+	history.Close()
+	configStore.Close()
+
+	var out bytes.Buffer
+	configRollbackCmd.SetOut(&out)
+	require.NoError(t, configRollbackCmdF(configRollbackCmd, []string{oldID}))
+	assert.Contains(t, out.String(), "ServiceSettings.SiteURL")
+	assert.NotContains(t, out.String(), "AdminAccessToken")
+
+	rolledBack, err := config.NewFileStore(path, false)
+	require.NoError(t, err)
+	defer rolledBack.Close()
+	assert.Equal(t, "https://old.example.com", *rolledBack.Get().ServiceSettings.SiteURL)
+	assert.Equal(t, "current-token", *rolledBack.Get().ServiceSettings.AdminAccessToken)
+}
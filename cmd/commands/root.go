@@ -19,7 +19,7 @@ var RootCmd = &cobra.Command{
 }
 
 func init() {
-	RootCmd.PersistentFlags().StringP("config", "c", "config.json", "Configuration file to use.")
+	RootCmd.PersistentFlags().StringP("config", "c", "config.json", "Configuration file or SQL data source name (mysql:// or postgres://) to use.")
 	RootCmd.PersistentFlags().Bool("disableconfigwatch", false, "When set config.json will not be loaded from disk when the file is changed.")
 
 	viper.BindEnv("config")
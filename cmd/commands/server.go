@@ -52,7 +52,6 @@ func runServer(configStore config.Store, interruptChan chan os.Signal) error {
 		mlog.Critical(err.Error())
 		return err
 	}
-	defer server.Shutdown()
 
 	api.Init(server, server.AppOptions, server.Router)
 	web.New(server, server.AppOptions, server.Router)
@@ -60,13 +59,31 @@ func runServer(configStore config.Store, interruptChan chan os.Signal) error {
 	serverErr := server.Start()
 	if serverErr != nil {
 		mlog.Critical(serverErr.Error())
+		server.Shutdown()
 		return serverErr
 	}
 
+	// reopen the log file on SIGHUP, as sent by logrotate after moving it away
+	hangupChan := make(chan os.Signal, 1)
+	signal.Notify(hangupChan, syscall.SIGHUP)
+	defer func() {
+		signal.Stop(hangupChan)
+		close(hangupChan)
+	}()
+	go func() {
+		for range hangupChan {
+			mlog.Info("Received hangup signal, reopening log file")
+			if err := server.Log.Reopen(); err != nil {
+				mlog.Error("Failed to reopen log file", mlog.Err(err))
+			}
+		}
+	}()
+
 	// wait for kill signal before attempting to gracefully shutdown
 	// the running service
 	signal.Notify(interruptChan, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-	<-interruptChan
+	sig := <-interruptChan
+	mlog.Info("Received shutdown signal", mlog.String("signal", sig.String()))
 
-	return nil
+	return server.Shutdown()
 }
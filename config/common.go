@@ -18,6 +18,9 @@ type commonStore struct {
 	config                 *model.Config
 	configWithoutOverrides *model.Config
 	environmentOverrides   map[string]interface{}
+
+	loadErrLock sync.RWMutex
+	loadErr     error
 }
 
 // Get fetches the current, cached configuration.
@@ -36,6 +39,23 @@ func (cs *commonStore) GetEnvironmentOverrides() map[string]interface{} {
 	return cs.environmentOverrides
 }
 
+// LoadError returns the error of the last attempt to load the configuration, or nil if it
+// succeeded or a configuration was set since.
+func (cs *commonStore) LoadError() error {
+	cs.loadErrLock.RLock()
+	defer cs.loadErrLock.RUnlock()
+
+	return cs.loadErr
+}
+
+// setLoadError records the outcome of an attempt to load the configuration.
+func (cs *commonStore) setLoadError(err error) {
+	cs.loadErrLock.Lock()
+	defer cs.loadErrLock.Unlock()
+
+	cs.loadErr = err
+}
+
 // set replaces the current configuration in its entirety, and updates the backing store
 // using the persist function argument.
 //
@@ -71,6 +91,9 @@ func (cs *commonStore) set(newCfg *model.Config, allowEnvironmentOverrides bool,
 
 	unlockOnce.Do(cs.configLock.Unlock)
 
+	// The persisted configuration replaces whatever failed to load before.
+	cs.setLoadError(nil)
+
 	// Notify listeners synchronously. Ideally, this would be asynchronous, but existing code
 	// assumes this and there would be increased complexity to avoid racing updates.
 	cs.invokeConfigListeners(oldCfg, newCfg)
@@ -111,6 +134,8 @@ func (cs *commonStore) load(f io.ReadCloser, needsSave bool, validate func(*mode
 	var unlockOnce sync.Once
 	defer unlockOnce.Do(cs.configLock.Unlock)
 
+	oldCfg := cs.config
+
 	if needsSave && persist != nil {
 		cfgWithoutEnvOverrides := removeEnvOverrides(loadedCfg, loadedCfgWithoutEnvOverrides, environmentOverrides)
 		if err = persist(cfgWithoutEnvOverrides); err != nil {
@@ -124,6 +149,12 @@ func (cs *commonStore) load(f io.ReadCloser, needsSave bool, validate func(*mode
 
 	unlockOnce.Do(cs.configLock.Unlock)
 
+	// The initial load has nothing to compare against, so only notify on subsequent reloads, and
+	// only if they changed any setting, as the server reloads its configuration on every start.
+	if oldCfg != nil && len(Diff(oldCfg, loadedCfg)) > 0 {
+		cs.invokeConfigListeners(oldCfg, loadedCfg)
+	}
+
 	return nil
 }
 
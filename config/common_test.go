@@ -0,0 +1,44 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+func TestLoadInvokesListenersOnlyOnChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-common")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	path := filepath.Join(dir, "config.json")
+	fs, err := NewFileStore(path, false)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		fs.Close()
+	})
+
+	var calls int
+	fs.AddListener(func(oldCfg, newCfg *model.Config) {
+		calls++
+	})
+
+	require.NoError(t, fs.Load())
+	assert.Equal(t, 0, calls, "no listener fires on a reload without changes")
+
+	cfg := fs.Get().Clone()
+	*cfg.ServiceSettings.SiteURL = "http://example.com"
+	require.NoError(t, ioutil.WriteFile(path, []byte(cfg.ToJSON()), 0600))
+
+	require.NoError(t, fs.Load())
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "http://example.com", *fs.Get().ServiceSettings.SiteURL)
+}
@@ -0,0 +1,466 @@
+package config
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	// Load the MySQL driver
+	_ "github.com/go-sql-driver/mysql"
+	// Load the Postgres driver
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/topoface/snippet-challenge/mlog"
+	"github.com/topoface/snippet-challenge/model"
+)
+
+const (
+	// databaseStorePollInterval is how often a database store checks for configuration changes
+	// made by other nodes in the cluster.
+	databaseStorePollInterval = 5 * time.Second
+
+	// databaseStoreMaxFileNameLength is the size of the Name column of the ConfigurationFiles table.
+	databaseStoreMaxFileNameLength = 64
+
+	// databaseStoreMaxConfigurations is the number of rows kept in the Configurations table,
+	// including the active configuration. Older rows are removed as new configurations are saved.
+	databaseStoreMaxConfigurations = 20
+)
+
+// DatabaseStore is a config store backed by a database.
+//
+// Every change to the configuration is kept as a row of the Configurations table, with the
+// active configuration flagged, up to databaseStoreMaxConfigurations rows, and configuration files are stored in the ConfigurationFiles
+// table. Other nodes sharing the database pick up changes by polling for the active row.
+type DatabaseStore struct {
+	commonStore
+
+	originalDsn    string
+	driverName     string
+	dataSourceName string
+	db             *sql.DB
+
+	activeIDLock sync.Mutex
+	activeID     string
+
+	pollInterval time.Duration
+	stopPolling  chan struct{}
+	pollingDone  chan struct{}
+}
+
+// IsDatabaseDSN returns true if the given data source name is a SQL connection string supported
+// by NewDatabaseStore.
+func IsDatabaseDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, "mysql://") ||
+		strings.HasPrefix(dsn, "postgres://") ||
+		strings.HasPrefix(dsn, "postgresql://")
+}
+
+// parseDSN splits a data source name into the name of the database/sql driver and the data
+// source name understood by that driver.
+func parseDSN(dsn string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(dsn, "mysql://"):
+		// The MySQL driver does not accept the scheme.
+		return "mysql", strings.TrimPrefix(dsn, "mysql://"), nil
+	case strings.HasPrefix(dsn, "postgres://"), strings.HasPrefix(dsn, "postgresql://"):
+		return "postgres", dsn, nil
+	default:
+		return "", "", fmt.Errorf("unsupported scheme in data source name")
+	}
+}
+
+// NewDatabaseStore creates a new instance of a config store backed by the given database.
+//
+// If watch is true, the store polls the database and reloads when another node changes the
+// active configuration.
+func NewDatabaseStore(dsn string, watch bool) (*DatabaseStore, error) {
+	driverName, dataSourceName, err := parseDSN(dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid DSN")
+	}
+
+	pollInterval := time.Duration(0)
+	if watch {
+		pollInterval = databaseStorePollInterval
+	}
+
+	return newDatabaseStore(dsn, driverName, dataSourceName, pollInterval)
+}
+
+func newDatabaseStore(originalDsn, driverName, dataSourceName string, pollInterval time.Duration) (ds *DatabaseStore, err error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to %s database", driverName)
+	}
+
+	ds = &DatabaseStore{
+		originalDsn:    originalDsn,
+		driverName:     driverName,
+		dataSourceName: dataSourceName,
+		db:             db,
+		pollInterval:   pollInterval,
+	}
+
+	defer func() {
+		if err != nil {
+			db.Close()
+		}
+	}()
+
+	if err = ds.initializeConfigurationsTables(); err != nil {
+		return nil, errors.Wrap(err, "failed to initialize")
+	}
+
+	if err = ds.Load(); err != nil {
+		return nil, errors.Wrap(err, "failed to load")
+	}
+
+	if ds.pollInterval > 0 {
+		ds.startPolling()
+	}
+
+	return ds, nil
+}
+
+// initializeConfigurationsTables ensures the requisite tables are in place to house the
+// configuration and configuration files.
+func (ds *DatabaseStore) initializeConfigurationsTables() error {
+	valueType, dataType := "TEXT", "BYTEA"
+	switch ds.driverName {
+	case "mysql":
+		valueType, dataType = "MEDIUMTEXT", "MEDIUMBLOB"
+	case "sqlite3":
+		dataType = "BLOB"
+	}
+
+	if _, err := ds.db.Exec(fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS Configurations (
+			Id VARCHAR(26) PRIMARY KEY,
+			Value %s NOT NULL,
+			CreateAt BIGINT NOT NULL,
+			Active BOOLEAN NULL UNIQUE
+		)
+	`, valueType)); err != nil {
+		return errors.Wrap(err, "failed to create Configurations table")
+	}
+
+	if _, err := ds.db.Exec(fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS ConfigurationFiles (
+			Name VARCHAR(%d) PRIMARY KEY,
+			Data %s NOT NULL,
+			CreateAt BIGINT NOT NULL,
+			UpdateAt BIGINT NOT NULL
+		)
+	`, databaseStoreMaxFileNameLength, dataType)); err != nil {
+		return errors.Wrap(err, "failed to create ConfigurationFiles table")
+	}
+
+	return nil
+}
+
+// rebind converts the ? placeholders of query to the placeholder syntax of the driver.
+func (ds *DatabaseStore) rebind(query string) string {
+	if ds.driverName != "postgres" {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// Set replaces the current configuration in its entirety and updates the backing store.
+func (ds *DatabaseStore) Set(newCfg *model.Config) (*model.Config, error) {
+	return ds.commonStore.set(newCfg, true, ds.commonStore.validate, ds.persist)
+}
+
+// persist writes the configuration as a new active row, keeping the most recent previous rows as
+// history.
+func (ds *DatabaseStore) persist(cfg *model.Config) error {
+	b, err := marshalConfig(cfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize")
+	}
+	value := string(b)
+
+	tx, err := ds.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		// Rollback after Commit just returns sql.ErrTxDone.
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			mlog.Error("Failed to rollback configuration transaction", mlog.Err(err))
+		}
+	}()
+
+	var activeID, activeValue string
+	err = tx.QueryRow(ds.rebind("SELECT Id, Value FROM Configurations WHERE Active = ?"), true).Scan(&activeID, &activeValue)
+	if err != nil && err != sql.ErrNoRows {
+		return errors.Wrap(err, "failed to query active configuration")
+	}
+
+	// Skip the write entirely if nothing has changed.
+	if activeValue == value {
+		ds.setActiveID(activeID)
+		return nil
+	}
+
+	if _, err = tx.Exec(ds.rebind("UPDATE Configurations SET Active = NULL WHERE Active = ?"), true); err != nil {
+		return errors.Wrap(err, "failed to deactivate current configuration")
+	}
+
+	id := model.NewID()
+	if _, err = tx.Exec(ds.rebind("INSERT INTO Configurations (Id, Value, CreateAt, Active) VALUES (?, ?, ?, ?)"), id, value, model.GetMillis(), true); err != nil {
+		return errors.Wrap(err, "failed to record new configuration")
+	}
+
+	if err = ds.pruneConfigurations(tx); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	ds.setActiveID(id)
+
+	return nil
+}
+
+// pruneConfigurations removes the oldest inactive rows of the Configurations table past
+// databaseStoreMaxConfigurations. The table is pruned on every write, so only a few rows are read.
+func (ds *DatabaseStore) pruneConfigurations(tx *sql.Tx) error {
+	rows, err := tx.Query(ds.rebind("SELECT Id FROM Configurations WHERE Active IS NULL ORDER BY CreateAt DESC"))
+	if err != nil {
+		return errors.Wrap(err, "failed to query previous configurations")
+	}
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return errors.Wrap(err, "failed to scan previous configuration")
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return errors.Wrap(err, "failed to query previous configurations")
+	}
+
+	// The active row counts towards the maximum.
+	if len(ids) < databaseStoreMaxConfigurations {
+		return nil
+	}
+
+	for _, id := range ids[databaseStoreMaxConfigurations-1:] {
+		if _, err = tx.Exec(ds.rebind("DELETE FROM Configurations WHERE Id = ?"), id); err != nil {
+			return errors.Wrap(err, "failed to remove previous configuration")
+		}
+	}
+
+	return nil
+}
+
+// Load updates the current configuration from the backing store, initializing it with the
+// default configuration if the database holds none yet.
+func (ds *DatabaseStore) Load() (err error) {
+	defer func() { ds.setLoadError(err) }()
+
+	var needsSave bool
+	var id string
+	var configurationData []byte
+
+	row := ds.db.QueryRow(ds.rebind("SELECT Id, Value FROM Configurations WHERE Active = ?"), true)
+	if err = row.Scan(&id, &configurationData); err != nil && err != sql.ErrNoRows {
+		return errors.Wrap(err, "failed to query active configuration")
+	}
+
+	// Initialize from the default config if no active configuration could be found.
+	if len(configurationData) == 0 {
+		needsSave = true
+
+		defaultCfg := &model.Config{}
+		defaultCfg.SetDefaults()
+
+		configurationData, err = marshalConfig(defaultCfg)
+		if err != nil {
+			return errors.Wrap(err, "failed to serialize default config")
+		}
+	}
+
+	if err = ds.commonStore.load(ioutil.NopCloser(bytes.NewReader(configurationData)), needsSave, ds.commonStore.validate, ds.persist); err != nil {
+		return err
+	}
+
+	if !needsSave {
+		ds.setActiveID(id)
+	}
+
+	return nil
+}
+
+func (ds *DatabaseStore) setActiveID(id string) {
+	ds.activeIDLock.Lock()
+	defer ds.activeIDLock.Unlock()
+
+	ds.activeID = id
+}
+
+func (ds *DatabaseStore) getActiveID() string {
+	ds.activeIDLock.Lock()
+	defer ds.activeIDLock.Unlock()
+
+	return ds.activeID
+}
+
+// startPolling periodically reloads the configuration when another node activated a new one.
+func (ds *DatabaseStore) startPolling() {
+	ds.stopPolling = make(chan struct{})
+	ds.pollingDone = make(chan struct{})
+
+	go func() {
+		defer close(ds.pollingDone)
+
+		ticker := time.NewTicker(ds.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ds.checkForChanges()
+			case <-ds.stopPolling:
+				return
+			}
+		}
+	}()
+}
+
+// checkForChanges reloads the configuration if the active row differs from the loaded one.
+func (ds *DatabaseStore) checkForChanges() {
+	var id string
+	err := ds.db.QueryRow(ds.rebind("SELECT Id FROM Configurations WHERE Active = ?"), true).Scan(&id)
+	if err == sql.ErrNoRows {
+		return
+	} else if err != nil {
+		mlog.Error("Failed to poll for configuration changes", mlog.String("store", ds.String()), mlog.Err(err))
+		return
+	}
+
+	if id == ds.getActiveID() {
+		return
+	}
+
+	mlog.Info("Reloading configuration changed in the database", mlog.String("id", id))
+	if err := ds.Load(); err != nil {
+		mlog.Error("Failed to reload configuration changed in the database", mlog.String("id", id), mlog.Err(err))
+
+		// Don't retry an invalid configuration on every poll, wait for the next change instead.
+		ds.setActiveID(id)
+	}
+}
+
+// GetFile fetches the contents of a previously persisted configuration file.
+func (ds *DatabaseStore) GetFile(name string) ([]byte, error) {
+	var data []byte
+	row := ds.db.QueryRow(ds.rebind("SELECT Data FROM ConfigurationFiles WHERE Name = ?"), name)
+	if err := row.Scan(&data); err == sql.ErrNoRows {
+		return nil, errors.Errorf("file %s not stored", name)
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to scan data from row for %s", name)
+	}
+
+	return data, nil
+}
+
+// SetFile sets or replaces the contents of a configuration file.
+func (ds *DatabaseStore) SetFile(name string, data []byte) error {
+	if len(name) > databaseStoreMaxFileNameLength {
+		return errors.Errorf("file name %s is longer than %d characters", name, databaseStoreMaxFileNameLength)
+	}
+
+	tx, err := ds.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			mlog.Error("Failed to rollback configuration file transaction", mlog.Err(err))
+		}
+	}()
+
+	now := model.GetMillis()
+	result, err := tx.Exec(ds.rebind("UPDATE ConfigurationFiles SET Data = ?, UpdateAt = ? WHERE Name = ?"), data, now, name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update row for %s", name)
+	}
+
+	if count, err := result.RowsAffected(); err != nil {
+		return errors.Wrapf(err, "failed to count rows affected for %s", name)
+	} else if count == 0 {
+		if _, err = tx.Exec(ds.rebind("INSERT INTO ConfigurationFiles (Name, Data, CreateAt, UpdateAt) VALUES (?, ?, ?, ?)"), name, data, now, now); err != nil {
+			return errors.Wrapf(err, "failed to insert row for %s", name)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// HasFile returns true if the given file was previously persisted.
+func (ds *DatabaseStore) HasFile(name string) (bool, error) {
+	var count int64
+	row := ds.db.QueryRow(ds.rebind("SELECT COUNT(*) FROM ConfigurationFiles WHERE Name = ?"), name)
+	if err := row.Scan(&count); err != nil {
+		return false, errors.Wrapf(err, "failed to scan count of rows for %s", name)
+	}
+
+	return count != 0, nil
+}
+
+// RemoveFile removes a previously persisted configuration file.
+func (ds *DatabaseStore) RemoveFile(name string) error {
+	if _, err := ds.db.Exec(ds.rebind("DELETE FROM ConfigurationFiles WHERE Name = ?"), name); err != nil {
+		return errors.Wrapf(err, "failed to remove row for %s", name)
+	}
+
+	return nil
+}
+
+// String returns the data source name of the backing database, with any password masked.
+func (ds *DatabaseStore) String() string {
+	return model.MaskURLPassword(ds.originalDsn)
+}
+
+// Close stops polling for changes and closes the database connection.
+func (ds *DatabaseStore) Close() error {
+	if ds.stopPolling != nil {
+		close(ds.stopPolling)
+		<-ds.pollingDone
+		ds.stopPolling = nil
+	}
+
+	ds.configLock.Lock()
+	defer ds.configLock.Unlock()
+
+	return ds.db.Close()
+}
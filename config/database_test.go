@@ -0,0 +1,160 @@
+//go:build sqlite
+// +build sqlite
+
+// The database store tests run against SQLite, whose driver needs cgo. Run them with
+// go test -tags sqlite ./config/...
+// The tests that need no database are in database_dsn_test.go.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+// setupDatabaseStore opens a database store on a temporary SQLite database, which stands in for
+// MySQL and Postgres in tests.
+func setupDatabaseStore(t *testing.T, path string, pollInterval time.Duration) *DatabaseStore {
+	t.Helper()
+
+	ds, err := newDatabaseStore("sqlite3://"+path, "sqlite3", path, pollInterval)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, ds.Close())
+	})
+
+	return ds
+}
+
+func tempDatabasePath(t *testing.T) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "config-database-store")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	return filepath.Join(dir, "config.db")
+}
+
+func countConfigurations(t *testing.T, ds *DatabaseStore) int {
+	t.Helper()
+
+	var count int
+	require.NoError(t, ds.db.QueryRow("SELECT COUNT(*) FROM Configurations").Scan(&count))
+	return count
+}
+
+func TestDatabaseStoreNew(t *testing.T) {
+	ds := setupDatabaseStore(t, tempDatabasePath(t), 0)
+
+	defaultCfg := &model.Config{}
+	defaultCfg.SetDefaults()
+
+	assert.Equal(t, *defaultCfg.ServiceSettings.ListenAddress, *ds.Get().ServiceSettings.ListenAddress)
+	assert.Equal(t, 1, countConfigurations(t, ds), "default config should be persisted")
+}
+
+func TestDatabaseStoreSet(t *testing.T) {
+	ds := setupDatabaseStore(t, tempDatabasePath(t), 0)
+
+	newCfg := ds.Get().Clone()
+	newCfg.ServiceSettings.SiteURL = model.NewString("http://example.com")
+
+	oldCfg, err := ds.Set(newCfg)
+	require.NoError(t, err)
+	assert.Equal(t, "", *oldCfg.ServiceSettings.SiteURL)
+	assert.Equal(t, "http://example.com", *ds.Get().ServiceSettings.SiteURL)
+	assert.Equal(t, 2, countConfigurations(t, ds), "previous config should be kept as history")
+
+	// Saving an unchanged config does not add history.
+	_, err = ds.Set(ds.Get().Clone())
+	require.NoError(t, err)
+	assert.Equal(t, 2, countConfigurations(t, ds))
+
+	invalidCfg := ds.Get().Clone()
+	invalidCfg.ServiceSettings.ReadTimeout = model.NewInt(-1)
+	_, err = ds.Set(invalidCfg)
+	require.Error(t, err)
+	assert.Equal(t, 2, countConfigurations(t, ds))
+}
+
+func TestDatabaseStoreSetPrunesConfigurations(t *testing.T) {
+	ds := setupDatabaseStore(t, tempDatabasePath(t), 0)
+
+	for i := 0; i < databaseStoreMaxConfigurations+5; i++ {
+		newCfg := ds.Get().Clone()
+		newCfg.ServiceSettings.SiteURL = model.NewString(fmt.Sprintf("http://example.com/%d", i))
+		_, err := ds.Set(newCfg)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, databaseStoreMaxConfigurations, countConfigurations(t, ds))
+
+	// The active configuration is never pruned.
+	require.NoError(t, ds.Load())
+	assert.Equal(t, fmt.Sprintf("http://example.com/%d", databaseStoreMaxConfigurations+4), *ds.Get().ServiceSettings.SiteURL)
+}
+
+func TestDatabaseStorePolling(t *testing.T) {
+	path := tempDatabasePath(t)
+	writer := setupDatabaseStore(t, path, 0)
+	reader := setupDatabaseStore(t, path, 10*time.Millisecond)
+
+	called := make(chan *model.Config, 1)
+	reader.AddListener(func(oldCfg, newCfg *model.Config) {
+		called <- newCfg
+	})
+
+	newCfg := writer.Get().Clone()
+	newCfg.ServiceSettings.SiteURL = model.NewString("http://example.com")
+	_, err := writer.Set(newCfg)
+	require.NoError(t, err)
+
+	select {
+	case cfg := <-called:
+		assert.Equal(t, "http://example.com", *cfg.ServiceSettings.SiteURL)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "listener was not called after the config changed in the database")
+	}
+
+	assert.Equal(t, "http://example.com", *reader.Get().ServiceSettings.SiteURL)
+}
+
+func TestDatabaseStoreFiles(t *testing.T) {
+	ds := setupDatabaseStore(t, tempDatabasePath(t), 0)
+
+	has, err := ds.HasFile("saml.crt")
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	_, err = ds.GetFile("saml.crt")
+	require.Error(t, err)
+
+	require.NoError(t, ds.SetFile("saml.crt", []byte("first")))
+	require.NoError(t, ds.SetFile("saml.crt", []byte("second")))
+
+	has, err = ds.HasFile("saml.crt")
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	data, err := ds.GetFile("saml.crt")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("second"), data)
+
+	require.NoError(t, ds.RemoveFile("saml.crt"))
+	has, err = ds.HasFile("saml.crt")
+	require.NoError(t, err)
+	assert.False(t, has)
+}
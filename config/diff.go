@@ -0,0 +1,64 @@
+package config
+
+import (
+	"reflect"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+// leafValues returns the values of the leaf fields of cfg keyed by their dotted path, with pointers
+// dereferenced so that they compare and serialize by value.
+func leafValues(cfg *model.Config) map[string]interface{} {
+	values := make(map[string]interface{})
+	walkFields(reflect.ValueOf(cfg), "", false, func(path string, field reflect.Value, restricted bool) {
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				values[path] = nil
+				return
+			}
+			field = field.Elem()
+		}
+		values[path] = field.Interface()
+	})
+
+	return values
+}
+
+// Diff returns the settings whose values differ between oldCfg and newCfg, sorted in the order
+// the fields are declared. Secrets, as replaced by model.Config.Sanitize, are reported as changed
+// but with both values masked as model.FAKE_SETTING.
+func Diff(oldCfg, newCfg *model.Config) model.ConfigDiffs {
+	oldValues := leafValues(oldCfg)
+	newValues := leafValues(newCfg)
+
+	sanitizedOld := oldCfg.Clone()
+	sanitizedOld.Sanitize()
+	sanitizedOldValues := leafValues(sanitizedOld)
+
+	sanitizedNew := newCfg.Clone()
+	sanitizedNew.Sanitize()
+	sanitizedNewValues := leafValues(sanitizedNew)
+
+	var diffs model.ConfigDiffs
+	walkFields(reflect.ValueOf(newCfg), "", false, func(path string, field reflect.Value, restricted bool) {
+		if reflect.DeepEqual(oldValues[path], newValues[path]) {
+			return
+		}
+
+		diff := model.ConfigDiff{
+			Path:     path,
+			OldValue: sanitizedOldValues[path],
+			NewValue: sanitizedNewValues[path],
+		}
+
+		// A secret that was set on one side only would otherwise be shown in clear.
+		if !reflect.DeepEqual(sanitizedOldValues[path], oldValues[path]) || !reflect.DeepEqual(sanitizedNewValues[path], newValues[path]) {
+			diff.OldValue = model.FAKE_SETTING
+			diff.NewValue = model.FAKE_SETTING
+		}
+
+		diffs = append(diffs, diff)
+	})
+
+	return diffs
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+func TestDiff(t *testing.T) {
+	defaultCfg := &model.Config{}
+	defaultCfg.SetDefaults()
+
+	t.Run("identical configs", func(t *testing.T) {
+		assert.Empty(t, Diff(defaultCfg, defaultCfg.Clone()))
+	})
+
+	t.Run("changed settings", func(t *testing.T) {
+		newCfg := defaultCfg.Clone()
+		newCfg.ServiceSettings.SiteURL = model.NewString("http://example.com")
+		newCfg.LogSettings.ConsoleLevel = model.NewString("ERROR")
+		newCfg.CorsSettings.AllowedOrigins = []string{"http://example.com"}
+
+		diffs := Diff(defaultCfg, newCfg)
+		assert.Len(t, diffs, 3)
+		assert.Contains(t, diffs, model.ConfigDiff{
+			Path:     "ServiceSettings.SiteURL",
+			OldValue: "",
+			NewValue: "http://example.com",
+		})
+		assert.Contains(t, diffs, model.ConfigDiff{
+			Path:     "LogSettings.ConsoleLevel",
+			OldValue: *defaultCfg.LogSettings.ConsoleLevel,
+			NewValue: "ERROR",
+		})
+		assert.True(t, diffs.Match("CorsSettings.*"))
+	})
+
+	t.Run("masks secrets", func(t *testing.T) {
+		newCfg := defaultCfg.Clone()
+		newCfg.ServiceSettings.AdminAccessToken = model.NewString("secret")
+
+		assert.Equal(t, model.ConfigDiffs{{
+			Path:     "ServiceSettings.AdminAccessToken",
+			OldValue: model.FAKE_SETTING,
+			NewValue: model.FAKE_SETTING,
+		}}, Diff(defaultCfg, newCfg))
+	})
+}
+
+func TestKeyedListeners(t *testing.T) {
+	ms, err := NewMemoryStore()
+	require.NoError(t, err)
+
+	var logCalls, siteURLCalls, allCalls int
+	ms.AddKeyedListener(func(_, _ *model.Config) { logCalls++ }, "LogSettings.*")
+	siteURLID := ms.AddKeyedListener(func(_, _ *model.Config) { siteURLCalls++ }, "ServiceSettings.SiteURL")
+	ms.AddKeyedListener(func(_, _ *model.Config) { allCalls++ }, "*")
+
+	cfg := ms.Get().Clone()
+	cfg.ServiceSettings.SiteURL = model.NewString("http://example.com")
+	_, err = ms.Set(cfg)
+	require.NoError(t, err)
+
+	cfg = ms.Get().Clone()
+	cfg.LogSettings.ConsoleLevel = model.NewString("ERROR")
+	_, err = ms.Set(cfg)
+	require.NoError(t, err)
+
+	// Saving an unchanged config notifies no keyed listener.
+	_, err = ms.Set(ms.Get().Clone())
+	require.NoError(t, err)
+
+	ms.RemoveListener(siteURLID)
+	cfg = ms.Get().Clone()
+	cfg.ServiceSettings.SiteURL = model.NewString("http://other.example.com")
+	_, err = ms.Set(cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, logCalls)
+	assert.Equal(t, 1, siteURLCalls)
+	assert.Equal(t, 3, allCalls)
+}
@@ -8,7 +8,14 @@ import (
 
 // emitter enables threadsafe registration and broadcasting to configuration listeners
 type emitter struct {
-	listeners sync.Map
+	listeners      sync.Map
+	keyedListeners sync.Map
+}
+
+// keyedListener is a listener only interested in changes to some settings.
+type keyedListener struct {
+	keys     []string
+	listener Listener
 }
 
 // AddListener adds a callback function to invoke when the configuration is modified.
@@ -20,9 +27,25 @@ func (e *emitter) AddListener(listener Listener) string {
 	return id
 }
 
-// RemoveListener removes a callback function using an id returned from AddListener.
+// AddKeyedListener adds a callback function to invoke when any of the settings matched by keys
+// is modified. A key is either the path of a setting, such as "ServiceSettings.SiteURL", the
+// path of a section followed by ".*", such as "LogSettings.*", or "*" for every setting, as
+// matched by model.ConfigDiffs.Match.
+func (e *emitter) AddKeyedListener(listener Listener, keys ...string) string {
+	id := model.NewRandomString(32)
+
+	e.keyedListeners.Store(id, &keyedListener{
+		keys:     keys,
+		listener: listener,
+	})
+
+	return id
+}
+
+// RemoveListener removes a callback function using an id returned from AddListener or AddKeyedListener.
 func (e *emitter) RemoveListener(id string) {
 	e.listeners.Delete(id)
+	e.keyedListeners.Delete(id)
 }
 
 // invokeConfigListeners synchronously notifies all listeners about the configuration change.
@@ -33,4 +56,22 @@ func (e *emitter) invokeConfigListeners(oldCfg, newCfg *model.Config) {
 
 		return true
 	})
+
+	// Only diff the configurations if some listener needs it.
+	var changes model.ConfigDiffs
+	var diffed bool
+	e.keyedListeners.Range(func(key, value interface{}) bool {
+		keyed := value.(*keyedListener)
+
+		if !diffed {
+			changes = Diff(oldCfg, newCfg)
+			diffed = true
+		}
+
+		if changes.Match(keyed.keys...) {
+			keyed.listener(oldCfg, newCfg)
+		}
+
+		return true
+	})
 }
@@ -132,6 +132,8 @@ func (fs *FileStore) persist(cfg *model.Config) error {
 
 // Load updates the current configuration from the backing store.
 func (fs *FileStore) Load() (err error) {
+	defer func() { fs.setLoadError(err) }()
+
 	var needsSave bool
 	var f io.ReadCloser
 
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/topoface/snippet-challenge/mlog"
+	"github.com/topoface/snippet-challenge/model"
+)
+
+const (
+	// HistoryFileName is the name of the configuration file holding the configuration history.
+	HistoryFileName = "config_history.json"
+
+	// DefaultHistoryMaxEntries is the number of configurations kept by a History by default.
+	DefaultHistoryMaxEntries = 20
+)
+
+var (
+	// ErrHistoryEntryNotFound is returned when the requested configuration history entry does not exist.
+	ErrHistoryEntryNotFound = errors.New("configuration history entry not found")
+)
+
+// History records every configuration activated in a Store, up to a maximum number of entries,
+// so that a broken configuration can be rolled back. Entries are kept in the configuration file
+// named HistoryFileName of the store, alongside the configuration itself.
+type History struct {
+	store      Store
+	maxEntries int
+	listenerID string
+
+	mutex sync.Mutex
+}
+
+// NewHistory starts recording the configurations activated in the store, keeping at most
+// maxEntries of them. The active configuration is recorded if the history is empty.
+func NewHistory(store Store, maxEntries int) (*History, error) {
+	if maxEntries <= 0 {
+		maxEntries = DefaultHistoryMaxEntries
+	}
+
+	h := &History{
+		store:      store,
+		maxEntries: maxEntries,
+	}
+
+	if err := h.record(nil, store.Get()); err != nil {
+		return nil, errors.Wrap(err, "failed to record the active configuration")
+	}
+
+	h.listenerID = store.AddListener(func(oldCfg, newCfg *model.Config) {
+		if err := h.record(oldCfg, newCfg); err != nil {
+			mlog.Error("Failed to record configuration history", mlog.Err(err))
+		}
+	})
+
+	return h, nil
+}
+
+// RollbackConfig returns the configuration of the entry, with defaults set, to activate in place
+// of current. The admin access token keeps its current value, so that rolling back doesn't revive
+// a revoked token the history still holds.
+func RollbackConfig(entry *model.ConfigHistoryEntry, current *model.Config) *model.Config {
+	cfg := entry.Config.Clone()
+	cfg.SetDefaults()
+	cfg.ServiceSettings.AdminAccessToken = model.NewString(*current.ServiceSettings.AdminAccessToken)
+	return cfg
+}
+
+// hashConfig identifies the content of a configuration.
+func hashConfig(cfg *model.Config) (string, error) {
+	b, err := marshalConfig(cfg)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to serialize")
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// record appends newCfg to the history, unless it is already the latest entry, as happens when
+// several nodes sharing a store all observe the same change.
+func (h *History) record(oldCfg, newCfg *model.Config) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	// Environment overrides are not part of the stored configuration, so don't record them either.
+	cfg := h.store.RemoveEnvironmentOverrides(newCfg)
+
+	hash, err := hashConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.load()
+	if err != nil {
+		return err
+	}
+
+	var previous *model.Config
+	if len(entries) > 0 {
+		latest := entries[len(entries)-1]
+		if latest.Hash == hash {
+			return nil
+		}
+		previous = latest.Config
+	} else if oldCfg != nil {
+		previous = h.store.RemoveEnvironmentOverrides(oldCfg)
+	}
+
+	entry := &model.ConfigHistoryEntry{
+		ID:       model.NewID(),
+		CreateAt: model.GetMillis(),
+		Hash:     hash,
+		Config:   cfg,
+	}
+	if previous != nil {
+		entry.Changes = Diff(previous, cfg)
+	}
+
+	entries = append(entries, entry)
+	if len(entries) > h.maxEntries {
+		entries = entries[len(entries)-h.maxEntries:]
+	}
+
+	return h.save(entries)
+}
+
+// load reads the stored entries, oldest first.
+func (h *History) load() ([]*model.ConfigHistoryEntry, error) {
+	exists, err := h.store.HasFile(HistoryFileName)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check for configuration history")
+	} else if !exists {
+		return nil, nil
+	}
+
+	data, err := h.store.GetFile(HistoryFileName)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read configuration history")
+	}
+
+	var entries []*model.ConfigHistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, errors.Wrap(err, "failed to parse configuration history")
+	}
+
+	return entries, nil
+}
+
+func (h *History) save(entries []*model.ConfigHistoryEntry) error {
+	data, err := json.MarshalIndent(entries, "", "    ")
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize configuration history")
+	}
+
+	if err := h.store.SetFile(HistoryFileName, data); err != nil {
+		return errors.Wrap(err, "failed to write configuration history")
+	}
+
+	return nil
+}
+
+// Entries returns the recorded configurations, newest first.
+func (h *History) Entries() ([]*model.ConfigHistoryEntry, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	entries, err := h.load()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	return entries, nil
+}
+
+// Get returns the entry with the given id, or ErrHistoryEntryNotFound.
+func (h *History) Get(id string) (*model.ConfigHistoryEntry, error) {
+	entries, err := h.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.ID == id {
+			return entry, nil
+		}
+	}
+
+	return nil, ErrHistoryEntryNotFound
+}
+
+// Close stops recording configuration changes.
+func (h *History) Close() {
+	h.store.RemoveListener(h.listenerID)
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+func TestHistory(t *testing.T) {
+	setSiteURL := func(t *testing.T, ms *MemoryStore, siteURL string) {
+		t.Helper()
+
+		cfg := ms.Get().Clone()
+		cfg.ServiceSettings.SiteURL = model.NewString(siteURL)
+		_, err := ms.Set(cfg)
+		require.NoError(t, err)
+	}
+
+	t.Run("records the active config and every change", func(t *testing.T) {
+		ms, err := NewMemoryStore()
+		require.NoError(t, err)
+
+		history, err := NewHistory(ms, 10)
+		require.NoError(t, err)
+		defer history.Close()
+
+		setSiteURL(t, ms, "http://a.example.com")
+		setSiteURL(t, ms, "http://b.example.com")
+
+		entries, err := history.Entries()
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+
+		assert.Equal(t, "http://b.example.com", *entries[0].Config.ServiceSettings.SiteURL)
+		assert.Equal(t, model.ConfigDiffs{{
+			Path:     "ServiceSettings.SiteURL",
+			OldValue: "http://a.example.com",
+			NewValue: "http://b.example.com",
+		}}, entries[0].Changes)
+		assert.Empty(t, entries[2].Changes)
+
+		entry, err := history.Get(entries[2].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "", *entry.Config.ServiceSettings.SiteURL)
+
+		_, err = history.Get("unknown")
+		assert.Equal(t, ErrHistoryEntryNotFound, err)
+	})
+
+	t.Run("is bounded", func(t *testing.T) {
+		ms, err := NewMemoryStore()
+		require.NoError(t, err)
+
+		history, err := NewHistory(ms, 2)
+		require.NoError(t, err)
+		defer history.Close()
+
+		setSiteURL(t, ms, "http://a.example.com")
+		setSiteURL(t, ms, "http://b.example.com")
+		setSiteURL(t, ms, "http://c.example.com")
+
+		entries, err := history.Entries()
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "http://c.example.com", *entries[0].Config.ServiceSettings.SiteURL)
+		assert.Equal(t, "http://b.example.com", *entries[1].Config.ServiceSettings.SiteURL)
+	})
+
+	t.Run("skips unchanged configs", func(t *testing.T) {
+		ms, err := NewMemoryStore()
+		require.NoError(t, err)
+
+		history, err := NewHistory(ms, 10)
+		require.NoError(t, err)
+		defer history.Close()
+
+		// A second history on the same store, as on another node, must not duplicate entries.
+		other, err := NewHistory(ms, 10)
+		require.NoError(t, err)
+		defer other.Close()
+
+		setSiteURL(t, ms, "http://a.example.com")
+		require.NoError(t, ms.Load())
+
+		entries, err := history.Entries()
+		require.NoError(t, err)
+		assert.Len(t, entries, 2)
+	})
+
+	t.Run("masks secrets in changes", func(t *testing.T) {
+		ms, err := NewMemoryStore()
+		require.NoError(t, err)
+
+		history, err := NewHistory(ms, 10)
+		require.NoError(t, err)
+		defer history.Close()
+
+		cfg := ms.Get().Clone()
+		cfg.ServiceSettings.AdminAccessToken = model.NewString("secret")
+		_, err = ms.Set(cfg)
+		require.NoError(t, err)
+
+		entries, err := history.Entries()
+		require.NoError(t, err)
+		assert.Equal(t, model.ConfigDiffs{{
+			Path:     "ServiceSettings.AdminAccessToken",
+			OldValue: model.FAKE_SETTING,
+			NewValue: model.FAKE_SETTING,
+		}}, entries[0].Changes)
+		assert.Equal(t, "secret", *entries[0].Config.ServiceSettings.AdminAccessToken)
+	})
+}
@@ -81,6 +81,8 @@ func (ms *MemoryStore) persist(cfg *model.Config) error {
 
 // Load applies environment overrides to the default config as if a re-load had occurred.
 func (ms *MemoryStore) Load() (err error) {
+	defer func() { ms.setLoadError(err) }()
+
 	var cfgBytes []byte
 	cfgBytes, err = marshalConfig(ms.savedConfig)
 	if err != nil {
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+)
+
+// RestrictedTag is the struct tag marking configuration fields that may not be changed through
+// the admin API, nor shown by it, while ExperimentalSettings.RestrictSystemAdmin is enabled.
+const RestrictedTag = "restricted"
+
+// walkFields calls fn for every leaf field of the struct v, passing the dotted path of the field
+// and whether the field, or any struct containing it, is tagged restricted:"true". Pointers to
+// structs are followed, while slices, maps and other pointers are treated as leaves.
+func walkFields(v reflect.Value, path string, restricted bool, fn func(path string, field reflect.Value, restricted bool)) {
+	if v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct {
+		if v.IsNil() {
+			fn(path, v, restricted)
+			return
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		fn(path, v, restricted)
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if structField.PkgPath != "" {
+			// Unexported fields are never serialized, so they cannot be read or changed by an admin.
+			continue
+		}
+
+		fieldPath := structField.Name
+		if path != "" {
+			fieldPath = path + "." + fieldPath
+		}
+
+		walkFields(v.Field(i), fieldPath, restricted || structField.Tag.Get(RestrictedTag) == "true", fn)
+	}
+}
+
+// RestrictedFields returns the sorted dotted paths of the restricted fields of cfg, such as
+// "ServiceSettings.SiteURL".
+func RestrictedFields(cfg interface{}) []string {
+	var paths []string
+	walkFields(reflect.ValueOf(cfg), "", false, func(path string, field reflect.Value, restricted bool) {
+		if restricted {
+			paths = append(paths, path)
+		}
+	})
+
+	sort.Strings(paths)
+	return paths
+}
+
+// ChangedRestrictedFields returns the sorted dotted paths of the restricted fields whose value
+// differs between oldCfg and newCfg, which must be of the same type.
+func ChangedRestrictedFields(oldCfg, newCfg interface{}) []string {
+	oldValues := make(map[string]reflect.Value)
+	walkFields(reflect.ValueOf(oldCfg), "", false, func(path string, field reflect.Value, restricted bool) {
+		if restricted {
+			oldValues[path] = field
+		}
+	})
+
+	var changed []string
+	walkFields(reflect.ValueOf(newCfg), "", false, func(path string, field reflect.Value, restricted bool) {
+		if !restricted {
+			return
+		}
+
+		oldField, ok := oldValues[path]
+		if !ok || !reflect.DeepEqual(oldField.Interface(), field.Interface()) {
+			changed = append(changed, path)
+		}
+	})
+
+	sort.Strings(changed)
+	return changed
+}
+
+// RemoveRestrictedFields resets every restricted field of the struct pointed to by cfg to its zero
+// value, which for the pointer fields of model.Config serializes as null.
+func RemoveRestrictedFields(cfg interface{}) {
+	walkFields(reflect.ValueOf(cfg), "", false, func(path string, field reflect.Value, restricted bool) {
+		if restricted && field.CanSet() {
+			field.Set(reflect.Zero(field.Type()))
+		}
+	})
+}
+
+// RestoreRestrictedFields copies into target the restricted fields of actual that target leaves
+// at their zero value, so that a config previously passed through RemoveRestrictedFields can be
+// sent back without appearing to change them. Both arguments must point to structs of the same type.
+func RestoreRestrictedFields(actual, target interface{}) {
+	actualValues := make(map[string]reflect.Value)
+	walkFields(reflect.ValueOf(actual), "", false, func(path string, field reflect.Value, restricted bool) {
+		if restricted {
+			actualValues[path] = field
+		}
+	})
+
+	walkFields(reflect.ValueOf(target), "", false, func(path string, field reflect.Value, restricted bool) {
+		if !restricted || !field.CanSet() || !field.IsZero() {
+			return
+		}
+
+		actualField, ok := actualValues[path]
+		if !ok {
+			return
+		}
+
+		// Copy pointed to values so that target does not alias actual.
+		if actualField.Kind() == reflect.Ptr && !actualField.IsNil() {
+			copied := reflect.New(actualField.Type().Elem())
+			copied.Elem().Set(actualField.Elem())
+			actualField = copied
+		}
+		field.Set(actualField)
+	})
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+type testInnerSettings struct {
+	Open   *string
+	Secret *string `restricted:"true"`
+}
+
+type testOuterSettings struct {
+	Name       *string
+	Inner      testInnerSettings
+	Locked     testInnerSettings `restricted:"true"`
+	InnerPtr   *testInnerSettings
+	Hosts      []string `restricted:"true"`
+	unexported *string  `restricted:"true"`
+}
+
+type testConfig struct {
+	Outer testOuterSettings
+	Flag  *bool `restricted:"true"`
+}
+
+func newTestConfig() *testConfig {
+	return &testConfig{
+		Outer: testOuterSettings{
+			Name: model.NewString("name"),
+			Inner: testInnerSettings{
+				Open:   model.NewString("open"),
+				Secret: model.NewString("secret"),
+			},
+			Locked: testInnerSettings{
+				Open:   model.NewString("locked open"),
+				Secret: model.NewString("locked secret"),
+			},
+			InnerPtr: &testInnerSettings{
+				Open:   model.NewString("ptr open"),
+				Secret: model.NewString("ptr secret"),
+			},
+			Hosts:      []string{"a", "b"},
+			unexported: model.NewString("unexported"),
+		},
+		Flag: model.NewBool(true),
+	}
+}
+
+func TestRestrictedFields(t *testing.T) {
+	t.Run("nested structs", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"Flag",
+			"Outer.Hosts",
+			"Outer.Inner.Secret",
+			"Outer.InnerPtr.Secret",
+			"Outer.Locked.Open",
+			"Outer.Locked.Secret",
+		}, RestrictedFields(newTestConfig()))
+	})
+
+	t.Run("model config", func(t *testing.T) {
+		cfg := &model.Config{}
+		cfg.SetDefaults()
+
+		fields := RestrictedFields(cfg)
+		assert.Contains(t, fields, "ServiceSettings.SiteURL")
+		assert.Contains(t, fields, "ExperimentalSettings.RestrictSystemAdmin")
+		assert.NotContains(t, fields, "FileSettings.MaxFileSize")
+	})
+}
+
+func TestChangedRestrictedFields(t *testing.T) {
+	t.Run("no changes", func(t *testing.T) {
+		assert.Empty(t, ChangedRestrictedFields(newTestConfig(), newTestConfig()))
+	})
+
+	t.Run("unrestricted changes are ignored", func(t *testing.T) {
+		newCfg := newTestConfig()
+		newCfg.Outer.Name = model.NewString("other")
+		newCfg.Outer.Inner.Open = model.NewString("other")
+		newCfg.Outer.InnerPtr.Open = model.NewString("other")
+
+		assert.Empty(t, ChangedRestrictedFields(newTestConfig(), newCfg))
+	})
+
+	t.Run("restricted changes", func(t *testing.T) {
+		newCfg := newTestConfig()
+		newCfg.Flag = model.NewBool(false)
+		newCfg.Outer.Inner.Secret = model.NewString("other")
+		newCfg.Outer.Locked.Open = nil
+		newCfg.Outer.InnerPtr.Secret = model.NewString("other")
+		newCfg.Outer.Hosts = append(newCfg.Outer.Hosts, "c")
+
+		assert.Equal(t, []string{
+			"Flag",
+			"Outer.Hosts",
+			"Outer.Inner.Secret",
+			"Outer.InnerPtr.Secret",
+			"Outer.Locked.Open",
+		}, ChangedRestrictedFields(newTestConfig(), newCfg))
+	})
+
+	t.Run("model config", func(t *testing.T) {
+		oldCfg := &model.Config{}
+		oldCfg.SetDefaults()
+
+		newCfg := oldCfg.Clone()
+		*newCfg.FileSettings.MaxFileSize = 1
+		*newCfg.ServiceSettings.EnableDeveloper = true
+
+		assert.Equal(t, []string{"ServiceSettings.EnableDeveloper"}, ChangedRestrictedFields(oldCfg, newCfg))
+	})
+}
+
+func TestRemoveRestrictedFields(t *testing.T) {
+	cfg := newTestConfig()
+	RemoveRestrictedFields(cfg)
+
+	assert.Nil(t, cfg.Flag)
+	assert.Nil(t, cfg.Outer.Hosts)
+	assert.Nil(t, cfg.Outer.Inner.Secret)
+	assert.Nil(t, cfg.Outer.Locked.Open)
+	assert.Nil(t, cfg.Outer.Locked.Secret)
+	assert.Nil(t, cfg.Outer.InnerPtr.Secret)
+
+	assert.Equal(t, "name", *cfg.Outer.Name)
+	assert.Equal(t, "open", *cfg.Outer.Inner.Open)
+	assert.Equal(t, "ptr open", *cfg.Outer.InnerPtr.Open)
+	assert.Equal(t, "unexported", *cfg.Outer.unexported)
+}
+
+func TestRestoreRestrictedFields(t *testing.T) {
+	actual := newTestConfig()
+
+	target := newTestConfig()
+	RemoveRestrictedFields(target)
+	target.Outer.Name = model.NewString("other")
+	target.Outer.Inner.Secret = model.NewString("changed")
+
+	RestoreRestrictedFields(actual, target)
+
+	assert.Equal(t, []string{"Outer.Inner.Secret"}, ChangedRestrictedFields(actual, target))
+	assert.Equal(t, "other", *target.Outer.Name)
+	assert.Equal(t, "changed", *target.Outer.Inner.Secret)
+
+	require.NotNil(t, target.Flag)
+	assert.True(t, *target.Flag)
+	assert.False(t, target.Flag == actual.Flag, "restored pointers should not alias the actual config")
+}
@@ -0,0 +1,85 @@
+package mlog
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// rootCore is a generation of the cores a Logger writes to.
+type rootCore struct {
+	core       zapcore.Core
+	generation uint64
+}
+
+// coreHolder holds the current cores of a Logger, shared with every logger derived from it, so
+// that reconfiguring the Logger takes effect everywhere.
+type coreHolder struct {
+	root atomic.Value
+}
+
+func newCoreHolder(core zapcore.Core) *coreHolder {
+	h := &coreHolder{}
+	h.root.Store(&rootCore{core: core})
+	return h
+}
+
+func (h *coreHolder) load() *rootCore {
+	return h.root.Load().(*rootCore)
+}
+
+// swap replaces the current cores.
+func (h *coreHolder) swap(core zapcore.Core) {
+	h.root.Store(&rootCore{core: core, generation: h.load().generation + 1})
+}
+
+// dynamicCore is a zapcore.Core delegating to the current cores of a coreHolder. Fields added with
+// With are applied to the current cores lazily, once per generation.
+type dynamicCore struct {
+	holder *coreHolder
+	fields []Field
+	cached atomic.Value
+}
+
+func newDynamicCore(holder *coreHolder) *dynamicCore {
+	return &dynamicCore{holder: holder}
+}
+
+func (c *dynamicCore) current() zapcore.Core {
+	root := c.holder.load()
+	if len(c.fields) == 0 {
+		return root.core
+	}
+
+	if cached, ok := c.cached.Load().(*rootCore); ok && cached.generation == root.generation {
+		return cached.core
+	}
+
+	cached := &rootCore{core: root.core.With(c.fields), generation: root.generation}
+	c.cached.Store(cached)
+	return cached.core
+}
+
+func (c *dynamicCore) Enabled(level zapcore.Level) bool {
+	return c.holder.load().core.Enabled(level)
+}
+
+func (c *dynamicCore) With(fields []Field) zapcore.Core {
+	combined := make([]Field, 0, len(c.fields)+len(fields))
+	combined = append(combined, c.fields...)
+	combined = append(combined, fields...)
+
+	return &dynamicCore{holder: c.holder, fields: combined}
+}
+
+func (c *dynamicCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.current().Check(entry, checked)
+}
+
+func (c *dynamicCore) Write(entry zapcore.Entry, fields []Field) error {
+	return c.current().Write(entry, fields)
+}
+
+func (c *dynamicCore) Sync() error {
+	return c.holder.load().core.Sync()
+}
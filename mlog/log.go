@@ -1,9 +1,12 @@
 package mlog
 
 import (
+	"errors"
 	"io"
 	"log"
 	"os"
+	"reflect"
+	"sync"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -37,19 +40,46 @@ var Bool = zap.Bool
 var Duration = zap.Duration
 
 type LoggerConfiguration struct {
-	EnableConsole bool
-	ConsoleJSON   bool
-	ConsoleLevel  string
-	EnableFile    bool
-	FileJSON      bool
-	FileLevel     string
-	FileLocation  string
+	EnableConsole  bool
+	ConsoleJSON    bool
+	ConsoleLevel   string
+	EnableFile     bool
+	FileJSON       bool
+	FileLevel      string
+	FileLocation   string
+	FileMaxSize    int
+	FileMaxBackups int
+	FileMaxAge     int
+	FileCompress   bool
+	Sinks          []SinkConfiguration
+	Sampling       []SamplingConfiguration
+	RedactedFields []string
+}
+
+// clone copies the configuration, so that changes to the slices of either copy don't affect the other.
+func (c *LoggerConfiguration) clone() LoggerConfiguration {
+	clone := *c
+	clone.Sinks = append([]SinkConfiguration(nil), c.Sinks...)
+	clone.Sampling = append([]SamplingConfiguration(nil), c.Sampling...)
+	clone.RedactedFields = append([]string(nil), c.RedactedFields...)
+	return clone
 }
 
 type Logger struct {
 	zap          *zap.Logger
 	consoleLevel zap.AtomicLevel
 	fileLevel    zap.AtomicLevel
+	sinks        *loggerSinks
+}
+
+// loggerSinks is the configuration of the outputs of a Logger, shared with the loggers derived
+// from it.
+type loggerSinks struct {
+	mutex      sync.Mutex
+	config     LoggerConfiguration
+	fileWriter *fileWriter
+	extra      map[string]sink
+	holder     *coreHolder
 }
 
 func getZapLevel(level string) zapcore.Level {
@@ -77,43 +107,230 @@ func makeEncoder(json bool) zapcore.Encoder {
 	return zapcore.NewConsoleEncoder(encoderConfig)
 }
 
-func NewLogger(config *LoggerConfiguration) *Logger {
-	cores := []zapcore.Core{}
-	logger := &Logger{
-		consoleLevel: zap.NewAtomicLevelAt(getZapLevel(config.ConsoleLevel)),
-		fileLevel:    zap.NewAtomicLevelAt(getZapLevel(config.FileLevel)),
+// fileWriter writes to the log file, rotated once it reaches FileMaxSize megabytes, or 100 if 0.
+// Rotated files are removed once there are more than FileMaxBackups of them or they are older than
+// FileMaxAge days, if set.
+//
+// Unlike lumberjack.Logger, which reopens the file on the next write, it stays closed once closed,
+// so that an entry still being written through the outputs replaced by Reconfigure cannot reopen
+// the previous log file and leak it.
+type fileWriter struct {
+	mutex  sync.Mutex
+	logger *lumberjack.Logger
+	closed bool
+}
+
+var errFileWriterClosed = errors.New("log file is closed")
+
+func newFileWriter(config *LoggerConfiguration) *fileWriter {
+	return &fileWriter{
+		logger: &lumberjack.Logger{
+			Filename:   config.FileLocation,
+			MaxSize:    config.FileMaxSize,
+			MaxBackups: config.FileMaxBackups,
+			MaxAge:     config.FileMaxAge,
+			Compress:   config.FileCompress,
+		},
+	}
+}
+
+func (w *fileWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return 0, errFileWriterClosed
+	}
+
+	return w.logger.Write(p)
+}
+
+// Reopen closes the log file, to be reopened by the next write.
+func (w *fileWriter) Reopen() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return nil
 	}
 
+	return w.logger.Close()
+}
+
+// Close closes the log file for good.
+func (w *fileWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.closed = true
+	return w.logger.Close()
+}
+
+// makeCore combines the console, file and other outputs enabled by the configuration, redacting
+// and sampling entries as configured. Sinks that could not be opened are skipped.
+func (l *Logger) makeCore(config *LoggerConfiguration, fileWriter *fileWriter, sinks map[string]sink) zapcore.Core {
+	cores := []zapcore.Core{}
+
 	if config.EnableConsole {
 		writer := zapcore.Lock(os.Stderr)
-		core := zapcore.NewCore(makeEncoder(config.ConsoleJSON), writer, logger.consoleLevel)
-		cores = append(cores, core)
+		core := zapcore.NewCore(makeEncoder(config.ConsoleJSON), writer, l.consoleLevel)
+		cores = append(cores, newRedactingCore(core, config.RedactedFields))
+	}
+
+	if config.EnableFile {
+		writer := zapcore.AddSync(fileWriter)
+		core := zapcore.NewCore(makeEncoder(config.FileJSON), writer, l.fileLevel)
+		cores = append(cores, newRedactingCore(core, config.RedactedFields))
+	}
+
+	for i := range config.Sinks {
+		if extra, ok := sinks[config.Sinks[i].connectionKey()]; ok {
+			core := extra.core(makeEncoder(config.Sinks[i].JSON), getZapLevel(config.Sinks[i].Level))
+			cores = append(cores, newRedactingCore(core, config.RedactedFields))
+		}
+	}
+
+	return newLevelSamplerCore(zapcore.NewTee(cores...), config.Sampling)
+}
+
+func NewLogger(config *LoggerConfiguration) *Logger {
+	logger := &Logger{
+		consoleLevel: zap.NewAtomicLevelAt(getZapLevel(config.ConsoleLevel)),
+		fileLevel:    zap.NewAtomicLevelAt(getZapLevel(config.FileLevel)),
+		sinks: &loggerSinks{
+			config: config.clone(),
+		},
 	}
 
 	if config.EnableFile {
-		writer := zapcore.AddSync(&lumberjack.Logger{
-			Filename: config.FileLocation,
-			MaxSize:  100,
-			Compress: true,
-		})
-		core := zapcore.NewCore(makeEncoder(config.FileJSON), writer, logger.fileLevel)
-		cores = append(cores, core)
+		logger.sinks.fileWriter = newFileWriter(config)
 	}
 
-	combinedCore := zapcore.NewTee(cores...)
+	var errs []sinkError
+	logger.sinks.extra, _, errs = openSinks(config.Sinks, nil)
+	logger.sinks.holder = newCoreHolder(logger.makeCore(config, logger.sinks.fileWriter, logger.sinks.extra))
 
-	logger.zap = zap.New(combinedCore,
+	logger.zap = zap.New(newDynamicCore(logger.sinks.holder),
 		zap.AddCaller(),
 	)
 
+	logger.logSinkErrors(errs)
+
 	return logger
 }
 
+func (l *Logger) logSinkErrors(errs []sinkError) {
+	for _, sinkErr := range errs {
+		l.Error("Failed to open log sink",
+			String("type", sinkErr.config.Type),
+			String("address", sinkErr.config.Address),
+			Err(sinkErr.err),
+		)
+	}
+}
+
 func (l *Logger) ChangeLevels(config *LoggerConfiguration) {
 	l.consoleLevel.SetLevel(getZapLevel(config.ConsoleLevel))
 	l.fileLevel.SetLevel(getZapLevel(config.FileLevel))
 }
 
+// fileWriterChanged reports whether the log file must be reopened to apply the new configuration.
+func fileWriterChanged(old, new *LoggerConfiguration) bool {
+	return old.FileLocation != new.FileLocation ||
+		old.FileMaxSize != new.FileMaxSize ||
+		old.FileMaxBackups != new.FileMaxBackups ||
+		old.FileMaxAge != new.FileMaxAge ||
+		old.FileCompress != new.FileCompress
+}
+
+// Reconfigure applies a new configuration to the logger and to every logger derived from it with
+// With, StdLog and the like. Level changes take effect immediately. The outputs are rebuilt if the
+// encodings, the file location or the sinks changed, in which case the previous log file and the
+// sinks no longer configured are closed.
+func (l *Logger) Reconfigure(config *LoggerConfiguration) {
+	// Loggers such as the testing logger have fixed outputs.
+	if l.sinks == nil {
+		l.ChangeLevels(config)
+		return
+	}
+
+	l.sinks.mutex.Lock()
+	defer l.sinks.mutex.Unlock()
+
+	l.ChangeLevels(config)
+
+	old := l.sinks.config
+	l.sinks.config = config.clone()
+
+	// Levels are held by the outputs, so they don't need to be rebuilt for a level change.
+	old.ConsoleLevel = config.ConsoleLevel
+	old.FileLevel = config.FileLevel
+	if reflect.DeepEqual(old, *config) {
+		return
+	}
+
+	oldFileWriter := l.sinks.fileWriter
+	if !config.EnableFile {
+		l.sinks.fileWriter = nil
+	} else if !old.EnableFile || fileWriterChanged(&old, config) {
+		l.sinks.fileWriter = newFileWriter(config)
+	}
+
+	extra, unused, errs := openSinks(config.Sinks, l.sinks.extra)
+	l.sinks.extra = extra
+
+	l.sinks.holder.swap(l.makeCore(config, l.sinks.fileWriter, l.sinks.extra))
+
+	if oldFileWriter != nil && oldFileWriter != l.sinks.fileWriter {
+		oldFileWriter.Close()
+	}
+	for _, extra := range unused {
+		extra.Close()
+	}
+
+	l.logSinkErrors(errs)
+}
+
+// Close flushes and closes the log file and the other outputs of the logger. The logger should
+// not be used afterwards.
+func (l *Logger) Close() error {
+	if l.sinks == nil {
+		return nil
+	}
+
+	l.sinks.mutex.Lock()
+	defer l.sinks.mutex.Unlock()
+
+	var firstErr error
+	if l.sinks.fileWriter != nil {
+		firstErr = l.sinks.fileWriter.Close()
+	}
+	for _, extra := range l.sinks.extra {
+		if err := extra.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// Reopen closes the log file, to be reopened by the next write. This lets an external tool such as
+// logrotate move the log file away.
+func (l *Logger) Reopen() error {
+	if l.sinks == nil {
+		return nil
+	}
+
+	l.sinks.mutex.Lock()
+	defer l.sinks.mutex.Unlock()
+
+	if l.sinks.fileWriter == nil {
+		return nil
+	}
+
+	return l.sinks.fileWriter.Reopen()
+}
+
 func (l *Logger) SetConsoleLevel(level string) {
 	l.consoleLevel.SetLevel(getZapLevel(level))
 }
@@ -157,6 +374,11 @@ func (l *Logger) Sugar() *SugarLogger {
 	}
 }
 
+// Sync flushes any buffered log entries.
+func (l *Logger) Sync() error {
+	return l.zap.Sync()
+}
+
 func (l *Logger) Debug(message string, fields ...Field) {
 	l.zap.Debug(message, fields...)
 }
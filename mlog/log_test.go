@@ -0,0 +1,251 @@
+package mlog_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/mlog"
+)
+
+func TestLoggerReconfigure(t *testing.T) {
+	tempDir, err := ioutil.TempDir(os.TempDir(), "TestLoggerReconfigure")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	readLines := func(t *testing.T, path string) []string {
+		t.Helper()
+
+		logs, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		return strings.Split(strings.TrimSpace(string(logs)), "\n")
+	}
+
+	firstPath := filepath.Join(tempDir, "first.log")
+	config := &mlog.LoggerConfiguration{
+		EnableFile:   true,
+		FileJSON:     true,
+		FileLevel:    mlog.LevelInfo,
+		FileLocation: firstPath,
+	}
+
+	logger := mlog.NewLogger(config)
+	child := logger.With(mlog.String("source", "child"))
+
+	logger.Debug("hidden debug log")
+	child.Info("first info log")
+
+	config.FileLevel = mlog.LevelDebug
+	logger.Reconfigure(config)
+	child.Debug("visible debug log")
+
+	lines := readLines(t, firstPath)
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"msg":"first info log","source":"child"`)
+	assert.Contains(t, lines[1], `"msg":"visible debug log","source":"child"`)
+
+	secondPath := filepath.Join(tempDir, "second.log")
+	config.FileJSON = false
+	config.FileLocation = secondPath
+	logger.Reconfigure(config)
+	child.Info("second info log")
+
+	assert.Len(t, readLines(t, firstPath), 2, "the previous log file should no longer be written")
+
+	lines = readLines(t, secondPath)
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], "\tinfo\t")
+	assert.Contains(t, lines[0], "second info log\t{\"source\": \"child\"}")
+}
+
+func TestLoggerReconfigureWhileLogging(t *testing.T) {
+	if _, err := os.Stat("/proc/self/fd"); err != nil {
+		t.Skip("open files cannot be listed on this platform")
+	}
+
+	tempDir, err := ioutil.TempDir(os.TempDir(), "TestLoggerReconfigureWhileLogging")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	config := &mlog.LoggerConfiguration{
+		EnableFile:   true,
+		FileJSON:     true,
+		FileLevel:    mlog.LevelInfo,
+		FileLocation: filepath.Join(tempDir, "0.log"),
+	}
+	logger := mlog.NewLogger(config)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					logger.Info("log entry")
+				}
+			}
+		}()
+	}
+
+	for i, start := 1, time.Now(); time.Since(start) < 200*time.Millisecond; i++ {
+		config.FileLocation = filepath.Join(tempDir, fmt.Sprintf("%d.log", i))
+		logger.Reconfigure(config)
+		runtime.Gosched()
+	}
+
+	close(done)
+	wg.Wait()
+	require.NoError(t, logger.Close())
+
+	fds, err := ioutil.ReadDir("/proc/self/fd")
+	require.NoError(t, err)
+	for _, fd := range fds {
+		target, err := os.Readlink(filepath.Join("/proc/self/fd", fd.Name()))
+		if err == nil {
+			assert.False(t, strings.HasPrefix(target, tempDir), "%s was left open", target)
+		}
+	}
+}
+
+func TestLoggerRotation(t *testing.T) {
+	tempDir, err := ioutil.TempDir(os.TempDir(), "TestLoggerRotation")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	logger := mlog.NewLogger(&mlog.LoggerConfiguration{
+		EnableFile:     true,
+		FileJSON:       true,
+		FileLevel:      mlog.LevelInfo,
+		FileLocation:   filepath.Join(tempDir, "file.log"),
+		FileMaxSize:    1,
+		FileMaxBackups: 1,
+	})
+
+	// Write enough to rotate the 1MB log file twice.
+	message := strings.Repeat("x", 1024)
+	for i := 0; i < 2500; i++ {
+		logger.Info(message)
+	}
+
+	// Old backups are removed in the background.
+	assert.Eventually(t, func() bool {
+		files, err := ioutil.ReadDir(tempDir)
+		require.NoError(t, err)
+		return len(files) == 2
+	}, 5*time.Second, 10*time.Millisecond, "the log file and a single backup should be kept")
+
+	files, err := ioutil.ReadDir(tempDir)
+	require.NoError(t, err)
+	for _, file := range files {
+		assert.LessOrEqual(t, file.Size(), int64(1024*1024))
+	}
+}
+
+func TestLoggerReopen(t *testing.T) {
+	tempDir, err := ioutil.TempDir(os.TempDir(), "TestLoggerReopen")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	path := filepath.Join(tempDir, "file.log")
+	logger := mlog.NewLogger(&mlog.LoggerConfiguration{
+		EnableFile:   true,
+		FileJSON:     true,
+		FileLevel:    mlog.LevelInfo,
+		FileLocation: path,
+	})
+
+	logger.Info("before rotation")
+
+	// Move the log file away like logrotate does, then signal the logger.
+	rotatedPath := path + ".1"
+	require.NoError(t, os.Rename(path, rotatedPath))
+	logger.Info("after move")
+	require.NoError(t, logger.Reopen())
+	logger.Info("after reopen")
+
+	rotated, err := ioutil.ReadFile(rotatedPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(rotated), "before rotation")
+	assert.Contains(t, string(rotated), "after move")
+	assert.NotContains(t, string(rotated), "after reopen")
+
+	current, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(current), "after reopen")
+	assert.NotContains(t, string(current), "before rotation")
+}
+
+func TestLoggerSampling(t *testing.T) {
+	tempDir, err := ioutil.TempDir(os.TempDir(), "TestLoggerSampling")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	path := filepath.Join(tempDir, "file.log")
+	logger := mlog.NewLogger(&mlog.LoggerConfiguration{
+		EnableFile:   true,
+		FileJSON:     true,
+		FileLevel:    mlog.LevelInfo,
+		FileLocation: path,
+		Sampling: []mlog.SamplingConfiguration{
+			{Level: mlog.LevelError, Initial: 2, Thereafter: 5},
+		},
+	})
+
+	// Sampling counts per second, so start at the beginning of one.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+
+	for i := 0; i < 12; i++ {
+		logger.Error("sampled error log")
+		logger.With(mlog.Int("i", i)).Info("unsampled info log")
+	}
+
+	logs, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	// The first 2 errors are logged, then every 5th: the 7th and the 12th.
+	assert.Equal(t, 4, strings.Count(string(logs), "sampled error log"))
+	assert.Equal(t, 12, strings.Count(string(logs), "unsampled info log"))
+}
+
+func TestLoggerRedaction(t *testing.T) {
+	tempDir, err := ioutil.TempDir(os.TempDir(), "TestLoggerRedaction")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	path := filepath.Join(tempDir, "file.log")
+	logger := mlog.NewLogger(&mlog.LoggerConfiguration{
+		EnableFile:     true,
+		FileJSON:       true,
+		FileLevel:      mlog.LevelInfo,
+		FileLocation:   path,
+		RedactedFields: []string{"authorization", "snippet"},
+	})
+
+	logger.Info("direct", mlog.String("Authorization", "Bearer secret"), mlog.String("name", "visible"))
+	logger.With(mlog.String("snippet", "secret body")).Info("child")
+	logger.StdLog(mlog.String("snippet", "secret body")).Print("stdlog")
+
+	logs, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(logs)), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], `"Authorization":"[redacted]","name":"visible"`)
+	assert.Contains(t, lines[1], `"snippet":"[redacted]"`)
+	assert.Contains(t, lines[2], `"snippet":"[redacted]"`)
+	assert.NotContains(t, string(logs), "secret")
+}
@@ -0,0 +1,77 @@
+package mlog
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RedactedValue replaces the value of redacted fields.
+const RedactedValue = "[redacted]"
+
+// redactingCore masks the value of the fields with one of the redacted keys, compared without
+// regard to case, before they reach the encoders. Only top level fields are redacted, not the keys
+// of objects logged with Any.
+//
+// Since it writes every entry it checked to the wrapped core, it must wrap a single output rather
+// than a tee of outputs with different levels.
+type redactingCore struct {
+	zapcore.Core
+	keys map[string]bool
+}
+
+func newRedactingCore(core zapcore.Core, keys []string) zapcore.Core {
+	if len(keys) == 0 {
+		return core
+	}
+
+	redactedKeys := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		redactedKeys[strings.ToLower(key)] = true
+	}
+
+	return &redactingCore{
+		Core: core,
+		keys: redactedKeys,
+	}
+}
+
+// redact returns the fields with the redacted ones masked, copying them only if needed.
+func (c *redactingCore) redact(fields []Field) []Field {
+	var redacted []Field
+	for i, field := range fields {
+		if !c.keys[strings.ToLower(field.Key)] {
+			continue
+		}
+
+		if redacted == nil {
+			redacted = make([]Field, len(fields))
+			copy(redacted, fields)
+		}
+		redacted[i] = zap.String(field.Key, RedactedValue)
+	}
+
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+func (c *redactingCore) With(fields []Field) zapcore.Core {
+	return &redactingCore{
+		Core: c.Core.With(c.redact(fields)),
+		keys: c.keys,
+	}
+}
+
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []Field) error {
+	return c.Core.Write(entry, c.redact(fields))
+}
@@ -0,0 +1,70 @@
+package mlog
+
+import (
+	"math"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// samplingTick is the interval over which the first entries with a given level and message are
+// all logged.
+const samplingTick = time.Second
+
+// SamplingConfiguration limits the entries logged at a level: every second, the first Initial
+// entries with a given message are logged, then only one in Thereafter, or none if Thereafter is 0.
+type SamplingConfiguration struct {
+	Level      string
+	Initial    int
+	Thereafter int
+}
+
+// levelSamplerCore samples the entries of each configured level with its own sampler, leaving the
+// entries of other levels alone.
+type levelSamplerCore struct {
+	zapcore.Core
+	samplers map[zapcore.Level]zapcore.Core
+}
+
+func newLevelSamplerCore(core zapcore.Core, configs []SamplingConfiguration) zapcore.Core {
+	if len(configs) == 0 {
+		return core
+	}
+
+	samplers := make(map[zapcore.Level]zapcore.Core, len(configs))
+	for _, config := range configs {
+		var sampler zapcore.Core
+		if config.Thereafter > 0 {
+			sampler = zapcore.NewSampler(core, samplingTick, config.Initial, config.Thereafter)
+		} else {
+			// zap divides by Thereafter, so use a rate never reached within a tick instead.
+			sampler = zapcore.NewSampler(core, samplingTick, config.Initial, math.MaxInt32)
+		}
+		samplers[getZapLevel(config.Level)] = sampler
+	}
+
+	return &levelSamplerCore{
+		Core:     core,
+		samplers: samplers,
+	}
+}
+
+func (c *levelSamplerCore) With(fields []Field) zapcore.Core {
+	samplers := make(map[zapcore.Level]zapcore.Core, len(c.samplers))
+	for level, sampler := range c.samplers {
+		samplers[level] = sampler.With(fields)
+	}
+
+	return &levelSamplerCore{
+		Core:     c.Core.With(fields),
+		samplers: samplers,
+	}
+}
+
+func (c *levelSamplerCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if sampler, ok := c.samplers[entry.Level]; ok {
+		return sampler.Check(entry, checked)
+	}
+
+	return c.Core.Check(entry, checked)
+}
@@ -0,0 +1,99 @@
+package mlog
+
+import (
+	"fmt"
+	"strconv"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// SinkTypeSyslog writes to syslog, either the local syslog socket or a remote syslog server.
+	SinkTypeSyslog = "syslog"
+	// SinkTypeTCP ships log entries as lines over a TCP connection.
+	SinkTypeTCP = "tcp"
+	// SinkTypeUDP ships log entries as lines over UDP, one entry per datagram.
+	SinkTypeUDP = "udp"
+)
+
+// SinkConfiguration configures an output of a Logger in addition to the console and the file.
+type SinkConfiguration struct {
+	Type string
+	// Address is the host:port to ship log entries to. For syslog, an empty address selects the
+	// local syslog socket, and a remote address may be prefixed by tcp:// or udp://, the default.
+	Address string
+	Level   string
+	JSON    bool
+	// Tag identifies the program in syslog messages, the program name if empty.
+	Tag string
+	// BufferSize is the number of log entries a TCP or UDP sink holds while the remote end cannot
+	// keep up, 1000 if 0.
+	BufferSize int
+}
+
+// connectionKey identifies the connection of a sink, which is kept when only the level or the
+// encoding of the sink changes.
+func (c *SinkConfiguration) connectionKey() string {
+	return c.Type + "|" + c.Address + "|" + c.Tag + "|" + strconv.Itoa(c.BufferSize)
+}
+
+// sink is an output of a Logger, other than the console and the file.
+type sink interface {
+	// core returns a core writing the entries enabled by level to the sink.
+	core(encoder zapcore.Encoder, level zapcore.LevelEnabler) zapcore.Core
+
+	// Sync flushes the buffered entries.
+	Sync() error
+
+	// Close flushes the buffered entries and releases the connection.
+	Close() error
+}
+
+func openSink(config *SinkConfiguration) (sink, error) {
+	switch config.Type {
+	case SinkTypeSyslog:
+		return newSyslogSink(config)
+	case SinkTypeTCP, SinkTypeUDP:
+		return newNetworkSink(config.Type, config.Address, config.BufferSize), nil
+	default:
+		return nil, fmt.Errorf("unknown log sink type %q", config.Type)
+	}
+}
+
+// sinkError describes a sink that could not be opened.
+type sinkError struct {
+	config SinkConfiguration
+	err    error
+}
+
+// openSinks opens the sinks of the configurations, reusing the existing sinks with the same
+// connection. The existing sinks that were not reused are returned to be closed.
+func openSinks(configs []SinkConfiguration, existing map[string]sink) (opened map[string]sink, unused []sink, errs []sinkError) {
+	opened = make(map[string]sink, len(configs))
+	for i := range configs {
+		key := configs[i].connectionKey()
+		if _, ok := opened[key]; ok {
+			continue
+		}
+
+		if s, ok := existing[key]; ok {
+			opened[key] = s
+			continue
+		}
+
+		s, err := openSink(&configs[i])
+		if err != nil {
+			errs = append(errs, sinkError{config: configs[i], err: err})
+			continue
+		}
+		opened[key] = s
+	}
+
+	for key, s := range existing {
+		if _, ok := opened[key]; !ok {
+			unused = append(unused, s)
+		}
+	}
+
+	return opened, unused, errs
+}
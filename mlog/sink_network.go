@@ -0,0 +1,259 @@
+package mlog
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultSinkBufferSize = 1000
+
+	// sinkBackpressureTimeout is how long logging blocks on a full buffer before dropping the entry.
+	sinkBackpressureTimeout = 100 * time.Millisecond
+
+	sinkDialTimeout    = 5 * time.Second
+	sinkWriteTimeout   = 5 * time.Second
+	sinkFlushTimeout   = 5 * time.Second
+	sinkMinRetryDelay  = 100 * time.Millisecond
+	sinkMaxRetryDelay  = 30 * time.Second
+	sinkFlushPollDelay = 10 * time.Millisecond
+
+	// sinkMaxSendAttempts is how many times an entry is tried before it is dropped, about a minute
+	// and a half of backoff.
+	sinkMaxSendAttempts = 10
+)
+
+// networkSink ships log entries to a TCP or UDP endpoint from a buffer, so that logging does not
+// wait on the network. Entries are retried with backoff, up to sinkMaxSendAttempts times, while
+// the endpoint is unreachable, and dropped right away if they cannot be sent at all, such as a
+// datagram too large. When the buffer is full, logging blocks for up to sinkBackpressureTimeout
+// before the entry is dropped. The number of dropped entries is reported to the endpoint once it
+// catches up.
+type networkSink struct {
+	// Accessed atomically, so kept first for alignment.
+	pending int64
+	dropped int64
+
+	network string
+	address string
+
+	// encoder is the encoding of the most recent core, used for the dropped entries notice.
+	mutex   sync.Mutex
+	encoder zapcore.Encoder
+
+	queue     chan []byte
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newNetworkSink(network, address string, bufferSize int) *networkSink {
+	if bufferSize <= 0 {
+		bufferSize = defaultSinkBufferSize
+	}
+
+	s := &networkSink{
+		network: network,
+		address: address,
+		queue:   make(chan []byte, bufferSize),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go s.run()
+
+	return s
+}
+
+func (s *networkSink) core(encoder zapcore.Encoder, level zapcore.LevelEnabler) zapcore.Core {
+	s.mutex.Lock()
+	s.encoder = encoder
+	s.mutex.Unlock()
+
+	return zapcore.NewCore(encoder, s, level)
+}
+
+// Write queues an encoded entry. The entry is copied since zap reuses the buffer.
+func (s *networkSink) Write(p []byte) (int, error) {
+	entry := make([]byte, len(p))
+	copy(entry, p)
+
+	atomic.AddInt64(&s.pending, 1)
+
+	select {
+	case s.queue <- entry:
+		return len(p), nil
+	default:
+	}
+
+	timer := time.NewTimer(sinkBackpressureTimeout)
+	defer timer.Stop()
+
+	select {
+	case s.queue <- entry:
+	case <-timer.C:
+		atomic.AddInt64(&s.pending, -1)
+		atomic.AddInt64(&s.dropped, 1)
+	case <-s.stop:
+		atomic.AddInt64(&s.pending, -1)
+		atomic.AddInt64(&s.dropped, 1)
+	}
+
+	// A dropped entry is not an error of the caller, and reporting it through zap would only log
+	// to stderr for each entry.
+	return len(p), nil
+}
+
+func (s *networkSink) run() {
+	defer close(s.done)
+
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	for {
+		select {
+		case entry := <-s.queue:
+			s.send(&conn, entry)
+			atomic.AddInt64(&s.pending, -1)
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// send writes the entry, reconnecting and retrying with backoff until it succeeds, fails for a
+// reason retrying cannot fix, runs out of attempts or the sink is closed. Entries dropped so far are
+// reported first, in a write of their own.
+func (s *networkSink) send(conn *net.Conn, entry []byte) {
+	delay := sinkMinRetryDelay
+	for attempt := 1; ; attempt++ {
+		if *conn == nil {
+			c, err := net.DialTimeout(s.network, s.address, sinkDialTimeout)
+			if err == nil {
+				*conn = c
+			}
+		}
+
+		if *conn != nil {
+			err := s.sendDroppedNotice(*conn)
+			if err == nil {
+				err = s.write(*conn, entry)
+			}
+
+			if err == nil {
+				return
+			} else if isEntryError(err) {
+				atomic.AddInt64(&s.dropped, 1)
+				return
+			}
+
+			(*conn).Close()
+			*conn = nil
+		}
+
+		if attempt >= sinkMaxSendAttempts {
+			atomic.AddInt64(&s.dropped, 1)
+			return
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-s.stop:
+			return
+		}
+
+		delay *= 2
+		if delay > sinkMaxRetryDelay {
+			delay = sinkMaxRetryDelay
+		}
+	}
+}
+
+func (s *networkSink) write(conn net.Conn, data []byte) error {
+	conn.SetWriteDeadline(time.Now().Add(sinkWriteTimeout))
+	_, err := conn.Write(data)
+	return err
+}
+
+// sendDroppedNotice reports the entries dropped since the last notice, if any. They are counted
+// again if the notice cannot be sent.
+func (s *networkSink) sendDroppedNotice(conn net.Conn) error {
+	dropped := atomic.SwapInt64(&s.dropped, 0)
+	if dropped == 0 {
+		return nil
+	}
+
+	notice, err := s.droppedNotice(dropped)
+	if err == nil {
+		err = s.write(conn, notice)
+	}
+	if err != nil {
+		atomic.AddInt64(&s.dropped, dropped)
+		// The notice is small, so it failing on its own is not a reason to drop the entry.
+		if isEntryError(err) {
+			return nil
+		}
+	}
+
+	return err
+}
+
+// droppedNotice is the entry reporting entries dropped while the endpoint could not keep up,
+// encoded like the other entries of the sink.
+func (s *networkSink) droppedNotice(count int64) ([]byte, error) {
+	s.mutex.Lock()
+	encoder := s.encoder
+	s.mutex.Unlock()
+
+	if encoder == nil {
+		encoder = makeEncoder(true)
+	}
+
+	entry := zapcore.Entry{
+		Level:   zapcore.WarnLevel,
+		Time:    time.Now(),
+		Message: "Dropped log entries",
+	}
+	buf, err := encoder.EncodeEntry(entry, []Field{Int64("count", count)})
+	if err != nil {
+		return nil, err
+	}
+	defer buf.Free()
+
+	notice := make([]byte, buf.Len())
+	copy(notice, buf.Bytes())
+	return notice, nil
+}
+
+// Sync waits for the buffered entries to be shipped, for up to sinkFlushTimeout.
+func (s *networkSink) Sync() error {
+	deadline := time.Now().Add(sinkFlushTimeout)
+	for atomic.LoadInt64(&s.pending) > 0 {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%d log entries not shipped to %s://%s", atomic.LoadInt64(&s.pending), s.network, s.address)
+		}
+		time.Sleep(sinkFlushPollDelay)
+	}
+
+	return nil
+}
+
+func (s *networkSink) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		err = s.Sync()
+		close(s.stop)
+		<-s.done
+	})
+
+	return err
+}
@@ -0,0 +1,15 @@
+//go:build !plan9
+// +build !plan9
+
+package mlog
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isEntryError reports whether writing failed because of the entry itself, such as a datagram
+// larger than the network allows, in which case retrying the entry cannot succeed.
+func isEntryError(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE)
+}
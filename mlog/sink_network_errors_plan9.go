@@ -0,0 +1,7 @@
+package mlog
+
+// isEntryError reports whether writing failed because of the entry itself. Plan 9 doesn't report
+// such errors distinctly, so entries are retried like any other failure.
+func isEntryError(err error) bool {
+	return false
+}
@@ -0,0 +1,112 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package mlog
+
+import (
+	"log/syslog"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// syslogSink writes log entries to syslog with the priority matching their level.
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+// parseSyslogAddress splits a syslog address into the network and address to dial.
+func parseSyslogAddress(address string) (string, string) {
+	if address == "" {
+		return "", ""
+	}
+
+	for _, network := range []string{"tcp", "udp"} {
+		if strings.HasPrefix(address, network+"://") {
+			return network, strings.TrimPrefix(address, network+"://")
+		}
+	}
+
+	return "udp", address
+}
+
+func newSyslogSink(config *SinkConfiguration) (sink, error) {
+	network, address := parseSyslogAddress(config.Address)
+
+	writer, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_USER, config.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &syslogSink{writer: writer}, nil
+}
+
+func (s *syslogSink) core(encoder zapcore.Encoder, level zapcore.LevelEnabler) zapcore.Core {
+	return &syslogCore{
+		LevelEnabler: level,
+		encoder:      encoder,
+		writer:       s.writer,
+	}
+}
+
+// Sync does nothing, as syslog messages are not buffered.
+func (s *syslogSink) Sync() error {
+	return nil
+}
+
+func (s *syslogSink) Close() error {
+	return s.writer.Close()
+}
+
+// syslogCore is a zapcore.Core writing each entry as a syslog message.
+type syslogCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	writer  *syslog.Writer
+}
+
+func (c *syslogCore) With(fields []Field) zapcore.Core {
+	clone := &syslogCore{
+		LevelEnabler: c.LevelEnabler,
+		encoder:      c.encoder.Clone(),
+		writer:       c.writer,
+	}
+	for _, field := range fields {
+		field.AddTo(clone.encoder)
+	}
+
+	return clone
+}
+
+func (c *syslogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *syslogCore) Write(entry zapcore.Entry, fields []Field) error {
+	buf, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	message := strings.TrimSuffix(buf.String(), "\n")
+	buf.Free()
+
+	switch entry.Level {
+	case zapcore.DebugLevel:
+		return c.writer.Debug(message)
+	case zapcore.InfoLevel:
+		return c.writer.Info(message)
+	case zapcore.WarnLevel:
+		return c.writer.Warning(message)
+	case zapcore.ErrorLevel:
+		return c.writer.Err(message)
+	default:
+		return c.writer.Crit(message)
+	}
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
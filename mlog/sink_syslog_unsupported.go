@@ -0,0 +1,12 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package mlog
+
+import (
+	"errors"
+)
+
+func newSyslogSink(config *SinkConfiguration) (sink, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
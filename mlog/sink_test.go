@@ -0,0 +1,215 @@
+package mlog_test
+
+import (
+	"bufio"
+	"net"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/mlog"
+)
+
+// acceptLines accepts a single connection on the listener and sends the lines read from it.
+func acceptLines(t *testing.T, listener net.Listener) <-chan string {
+	t.Helper()
+
+	lines := make(chan string, 100)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	return lines
+}
+
+func receiveLine(t *testing.T, lines <-chan string) string {
+	t.Helper()
+
+	select {
+	case line := <-lines:
+		return line
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no log entry received")
+		return ""
+	}
+}
+
+func receivePacket(t *testing.T, conn net.PacketConn) string {
+	t.Helper()
+
+	buf := make([]byte, 64*1024)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	return string(buf[:n])
+}
+
+func TestTCPSink(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	lines := acceptLines(t, listener)
+
+	config := &mlog.LoggerConfiguration{
+		Sinks: []mlog.SinkConfiguration{{
+			Type:    mlog.SinkTypeTCP,
+			Address: listener.Addr().String(),
+			Level:   mlog.LevelWarn,
+			JSON:    true,
+		}},
+	}
+	logger := mlog.NewLogger(config)
+	defer logger.Close()
+
+	child := logger.With(mlog.String("source", "child"))
+	child.Info("filtered info log")
+	child.Warn("shipped warning log")
+	require.NoError(t, logger.Sync())
+
+	line := receiveLine(t, lines)
+	assert.Contains(t, line, `"level":"warn"`)
+	assert.Contains(t, line, `"msg":"shipped warning log","source":"child"`)
+
+	// Changing the level of the sink keeps its connection, the only one the listener accepts.
+	config.Sinks[0].Level = mlog.LevelInfo
+	logger.Reconfigure(config)
+	child.Info("shipped info log")
+	require.NoError(t, logger.Sync())
+
+	assert.Contains(t, receiveLine(t, lines), `"msg":"shipped info log"`)
+}
+
+func TestTCPSinkRetry(t *testing.T) {
+	// Find a free port, with nothing listening on it yet.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	logger := mlog.NewLogger(&mlog.LoggerConfiguration{
+		Sinks: []mlog.SinkConfiguration{{
+			Type:       mlog.SinkTypeTCP,
+			Address:    address,
+			Level:      mlog.LevelInfo,
+			JSON:       true,
+			BufferSize: 1,
+		}},
+	})
+	defer logger.Close()
+
+	// The first entry is retried, the second waits in the buffer and the third is dropped after
+	// blocking on the full buffer.
+	logger.Info("first log")
+	logger.Info("second log")
+	logger.Info("third log")
+
+	listener, err = net.Listen("tcp", address)
+	require.NoError(t, err)
+	defer listener.Close()
+	lines := acceptLines(t, listener)
+
+	var received []string
+	for len(received) < 3 {
+		received = append(received, receiveLine(t, lines))
+	}
+
+	// The drop is reported as soon as the endpoint is reachable again.
+	assert.Contains(t, received[0], `"msg":"Dropped log entries","count":1`)
+	assert.Contains(t, received[1], `"msg":"first log"`)
+	assert.Contains(t, received[2], `"msg":"second log"`)
+}
+
+func TestUDPSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	logger := mlog.NewLogger(&mlog.LoggerConfiguration{
+		Sinks: []mlog.SinkConfiguration{{
+			Type:    mlog.SinkTypeUDP,
+			Address: conn.LocalAddr().String(),
+			Level:   mlog.LevelInfo,
+			JSON:    false,
+		}},
+	})
+	defer logger.Close()
+
+	logger.Debug("filtered debug log")
+	logger.Info("shipped info log")
+
+	packet := receivePacket(t, conn)
+	assert.Contains(t, packet, "\tinfo\t")
+	assert.Contains(t, packet, "shipped info log")
+}
+
+func TestUDPSinkOversizedEntry(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	logger := mlog.NewLogger(&mlog.LoggerConfiguration{
+		Sinks: []mlog.SinkConfiguration{{
+			Type:    mlog.SinkTypeUDP,
+			Address: conn.LocalAddr().String(),
+			Level:   mlog.LevelInfo,
+			JSON:    false,
+		}},
+	})
+	defer logger.Close()
+
+	// An entry too large for a datagram is dropped rather than retried forever.
+	logger.Info(strings.Repeat("x", 70*1024))
+	logger.Info("shipped info log")
+
+	// The drop is reported in a datagram of its own, in the encoding of the sink.
+	notice := receivePacket(t, conn)
+	assert.Contains(t, notice, "\twarn\t")
+	assert.Contains(t, notice, "Dropped log entries\t{\"count\": 1}")
+	assert.NotContains(t, notice, "shipped info log")
+
+	assert.Contains(t, receivePacket(t, conn), "shipped info log")
+}
+
+func TestSyslogSink(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("syslog is not supported on this platform")
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	logger := mlog.NewLogger(&mlog.LoggerConfiguration{
+		Sinks: []mlog.SinkConfiguration{{
+			Type:    mlog.SinkTypeSyslog,
+			Address: "udp://" + conn.LocalAddr().String(),
+			Level:   mlog.LevelWarn,
+			JSON:    true,
+			Tag:     "snippet-test",
+		}},
+	})
+	defer logger.Close()
+
+	logger.Info("filtered info log")
+	logger.With(mlog.String("source", "child")).Warn("syslog warning log")
+
+	packet := receivePacket(t, conn)
+	// The priority is the user facility with the warning severity.
+	assert.True(t, strings.HasPrefix(packet, "<12>"), packet)
+	assert.Contains(t, packet, "snippet-test")
+	assert.Contains(t, packet, `"msg":"syslog warning log","source":"child"`)
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"encoding/json"
+)
+
+const (
+	AUDIT_STATUS_SUCCESS = "success"
+	AUDIT_STATUS_FAIL    = "fail"
+
+	AUDIT_ACTOR_ADMIN     = "admin"
+	AUDIT_ACTOR_ANONYMOUS = "anonymous"
+	// AUDIT_ACTOR_SYSTEM is the server itself, such as when it applies a changed config file.
+	AUDIT_ACTOR_SYSTEM = "system"
+	// AUDIT_ACTOR_CLI is an operator running a command line tool on the server.
+	AUDIT_ACTOR_CLI = "cli"
+
+	AUDIT_QUERY_DEFAULT_PER_PAGE = 60
+	AUDIT_QUERY_MAX_PER_PAGE     = 200
+)
+
+// Audit is a record of an operation, kept for compliance. Records are never modified once saved.
+type Audit struct {
+	ID        string `json:"id"`
+	CreateAt  int64  `json:"create_at"`
+	Event     string `json:"event"`
+	Actor     string `json:"actor"`
+	ClientIP  string `json:"client_ip"`
+	RequestID string `json:"request_id"`
+	Target    string `json:"target"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// PreSave fills in the id and creation time of a new audit record
+func (o *Audit) PreSave() {
+	if o.ID == "" {
+		o.ID = NewID()
+	}
+
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
+
+// Success marks the audited operation as successful
+func (o *Audit) Success() {
+	o.Status = AUDIT_STATUS_SUCCESS
+	o.Error = ""
+}
+
+// Fail marks the audited operation as failed with the given error
+func (o *Audit) Fail(err *AppError) {
+	o.Status = AUDIT_STATUS_FAIL
+	if err != nil {
+		o.Error = err.Message
+	}
+}
+
+// AuditListToJSON convert a list of Audit to a json string
+func AuditListToJSON(audits []*Audit) string {
+	if audits == nil {
+		audits = []*Audit{}
+	}
+	b, _ := json.Marshal(audits)
+	return string(b)
+}
+
+// AuditQuery selects audit records. Empty fields match every record, and Since and Until bound
+// the creation time in milliseconds, inclusively.
+type AuditQuery struct {
+	Event   string
+	Actor   string
+	Target  string
+	Status  string
+	Since   int64
+	Until   int64
+	Page    int
+	PerPage int
+}
+
+// Matches reports whether the audit record is selected by the query
+func (o *AuditQuery) Matches(audit *Audit) bool {
+	return (o.Event == "" || o.Event == audit.Event) &&
+		(o.Actor == "" || o.Actor == audit.Actor) &&
+		(o.Target == "" || o.Target == audit.Target) &&
+		(o.Status == "" || o.Status == audit.Status) &&
+		(o.Since == 0 || audit.CreateAt >= o.Since) &&
+		(o.Until == 0 || audit.CreateAt <= o.Until)
+}
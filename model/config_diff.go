@@ -0,0 +1,52 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ConfigDiff describes a configuration setting whose value changed.
+type ConfigDiff struct {
+	Path     string      `json:"path"`
+	OldValue interface{} `json:"old_value"`
+	NewValue interface{} `json:"new_value"`
+}
+
+// ConfigDiffs is the list of settings changed between two configurations.
+type ConfigDiffs []ConfigDiff
+
+// ToJSON convert a ConfigDiffs to a json string
+func (o ConfigDiffs) ToJSON() string {
+	if o == nil {
+		o = ConfigDiffs{}
+	}
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+// Match reports whether any of the changed settings is matched by one of the keys. A key is
+// either the path of a setting, such as "ServiceSettings.SiteURL", the path of a section
+// followed by ".*", such as "LogSettings.*", or "*" for every setting.
+func (o ConfigDiffs) Match(keys ...string) bool {
+	for _, diff := range o {
+		for _, key := range keys {
+			if matchConfigKey(key, diff.Path) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func matchConfigKey(key, path string) bool {
+	if key == "*" {
+		return true
+	}
+
+	if strings.HasSuffix(key, ".*") {
+		return strings.HasPrefix(path, strings.TrimSuffix(key, "*"))
+	}
+
+	return key == path
+}
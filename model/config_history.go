@@ -0,0 +1,24 @@
+package model
+
+import (
+	"encoding/json"
+)
+
+// ConfigHistoryEntry is a previously active configuration, along with the changes it made to the
+// configuration preceding it.
+type ConfigHistoryEntry struct {
+	ID       string      `json:"id"`
+	CreateAt int64       `json:"create_at"`
+	Hash     string      `json:"hash"`
+	Changes  ConfigDiffs `json:"changes"`
+	Config   *Config     `json:"config,omitempty"`
+}
+
+// ConfigHistoryEntryListToJSON convert a list of ConfigHistoryEntry to a json string
+func ConfigHistoryEntryListToJSON(entries []*ConfigHistoryEntry) string {
+	if entries == nil {
+		entries = []*ConfigHistoryEntry{}
+	}
+	b, _ := json.Marshal(entries)
+	return string(b)
+}
@@ -2,8 +2,19 @@ package model
 
 // Custom table names
 const (
-	STATUS    = "status"
-	STATUS_OK = "OK"
+	STATUS               = "status"
+	STATUS_OK            = "OK"
+	STATUS_UNHEALTHY     = "UNHEALTHY"
+	STATUS_SHUTTING_DOWN = "SHUTTING_DOWN"
 
-	API_URL_SUFFIX = ""
+	API_URL_SUFFIX_V1 = "/api/v1"
+	API_URL_SUFFIX_V2 = "/api/v2"
+	API_URL_SUFFIX    = API_URL_SUFFIX_V1
+
+	HEADER_REQUEST_ID  = "X-Request-ID"
+	HEADER_AUTH        = "Authorization"
+	HEADER_BEARER      = "BEARER"
+	HEADER_DEPRECATION = "Deprecation"
+	HEADER_SUNSET      = "Sunset"
+	HEADER_LINK        = "Link"
 )
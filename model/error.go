@@ -2,19 +2,23 @@ package model
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"strings"
 )
 
-var statusCode = map[string]int{
-	"Bad Request":           http.StatusBadRequest,
-	"Unauthorized":          http.StatusUnauthorized,
-	"Forbidden":             http.StatusForbidden,
-	"Not Found":             http.StatusNotFound,
-	"Method Not Allowed":    http.StatusMethodNotAllowed,
-	"Internal Server Error": http.StatusInternalServerError,
-}
+const (
+	// PROBLEM_CONTENT_TYPE is the media type of RFC 7807 problem details
+	PROBLEM_CONTENT_TYPE = "application/problem+json"
+	// PROBLEM_TYPE_PREFIX prefixes the error code to form the type URI of a problem
+	PROBLEM_TYPE_PREFIX = "urn:snippet-challenge:error:"
+	// PROBLEM_TYPE_BLANK is the type of problems with no more semantics than their status code
+	PROBLEM_TYPE_BLANK = "about:blank"
+)
+
+// TranslateFunc returns the message for the translation ID, filling in the given params.
+type TranslateFunc func(translationID string, params map[string]interface{}) string
 
 // Error structure
 type Error struct {
@@ -37,25 +41,56 @@ func (er *Error) MarshalJSON() ([]byte, error) {
 	return json.Marshal(er.message)
 }
 
+// Translate fills in the translated error message
+func (er *Error) Translate(T TranslateFunc) {
+	er.message = T(er.ID, er.params)
+}
+
+// translateErrors translates the errors in an AppError.Errors value, leaving other values as is.
+func translateErrors(v interface{}, T TranslateFunc) interface{} {
+	switch errs := v.(type) {
+	case *Error:
+		return T(errs.ID, errs.params)
+	case []*Error:
+		messages := make([]string, len(errs))
+		for i, err := range errs {
+			messages[i] = T(err.ID, err.params)
+		}
+		return messages
+	default:
+		return v
+	}
+}
+
 // AppError structure
 type AppError struct {
 	// for the end user
 	Errors     []map[string]interface{}
-	ErrorCode  string `json:"error"`
-	Message    string `json:"detail"` // Message to be display to the end user without debugging information
-	StatusCode int    `json:"-"`
-	Many       bool   `json:"-"`
+	ErrorCode  string     `json:"error"`
+	Message    string     `json:"detail"` // Message to be display to the end user without debugging information
+	StatusCode int        `json:"-"`
+	Many       bool       `json:"-"`
+	Kind       *ErrorKind `json:"-"` // The catalog entry of the error, if any
 
 	// for internal debug
-	Where         string `json:"-"` // The function where it happened in the form of Struct.Func
-	DetailedError string `json:"-"` // Internal error string to help the developer
-	ClientID      string `json:"-"` // The ClientID that's also set in the header
+	Where         string `json:"-"`                    // The function where it happened in the form of Struct.Func
+	DetailedError string `json:"-"`                    // Internal error string to help the developer
+	ClientID      string `json:"-"`                    // The ClientID that's also set in the header
+	RequestID     string `json:"request_id,omitempty"` // The RequestID that's also set in the header
 }
 
 func (er *AppError) Error() string {
 	return er.Where + ": " + er.Message + ", " + er.DetailedError
 }
 
+// Unwrap returns the kind of the error, so that errors.Is and errors.As match it
+func (er *AppError) Unwrap() error {
+	if er.Kind == nil {
+		return nil
+	}
+	return er.Kind
+}
+
 // NewAppError creates a new app error
 func NewAppError(where string, id string, params map[string]interface{}, details string, status int) *AppError {
 	ap := &AppError{}
@@ -98,19 +133,41 @@ func NewSimpleAppError(where string, id string, params map[string]interface{}, s
 	return NewAppErrorWithCode(where, id, params, "", "", status)
 }
 
+// Translate fills in the translated messages of all errors, and sets Message to the first of them.
+func (er *AppError) Translate(T TranslateFunc) {
+	for i, errMap := range er.Errors {
+		for _, v := range errMap {
+			switch errs := v.(type) {
+			case *Error:
+				errs.Translate(T)
+			case []*Error:
+				for _, err := range errs {
+					err.Translate(T)
+				}
+			}
+		}
+
+		if i == 0 {
+			if errs, ok := errMap["detail"].([]*Error); ok && len(errs) > 0 {
+				er.Message = errs[0].message
+			}
+		}
+	}
+}
+
 // SystemMessage translates app error to system message
-func (er *AppError) SystemMessage() string {
+func (er *AppError) SystemMessage(T TranslateFunc) string {
 	if len(er.Errors) == 1 {
 		if err, ok := er.Errors[0]["detail"]; ok {
 			errAry := err.([]*Error)
-			return errAry[0].message
+			return T(errAry[0].ID, errAry[0].params)
 		}
 	}
 	data := []map[string]interface{}{}
 	for _, errMap := range er.Errors {
 		temp := map[string]interface{}{}
 		for k, v := range errMap {
-			b, _ := json.Marshal(v)
+			b, _ := json.Marshal(translateErrors(v, T))
 			temp[k] = string(b)
 		}
 		data = append(data, temp)
@@ -157,128 +214,208 @@ func (er *AppError) ToJSON() string {
 			r["data"] = data
 		}
 	}
+	if er.RequestID != "" {
+		r["request_id"] = er.RequestID
+	}
+	if er.DetailedError != "" {
+		r["detailed_error"] = er.DetailedError
+	}
 
 	b, _ := json.Marshal(r)
 	return string(b)
 }
 
+// Problem is an RFC 7807 problem details object
+type Problem struct {
+	Type          string               `json:"type"`
+	Title         string               `json:"title"`
+	Status        int                  `json:"status"`
+	Detail        string               `json:"detail,omitempty"`
+	Instance      string               `json:"instance,omitempty"`
+	RequestID     string               `json:"request_id,omitempty"`
+	DetailedError string               `json:"detailed_error,omitempty"`
+	Errors        []*ProblemFieldError `json:"errors,omitempty"`
+}
+
+// ProblemFieldError is a validation error of a single field. Index is set when the request body
+// was a list, to the position of the invalid item.
+type ProblemFieldError struct {
+	Field  string `json:"field"`
+	Index  *int   `json:"index,omitempty"`
+	Detail string `json:"detail"`
+}
+
+// ToProblem converts a AppError to RFC 7807 problem details. The error should already be
+// translated, since the messages are copied as they are.
+func (er *AppError) ToProblem() *Problem {
+	p := &Problem{
+		Type:          PROBLEM_TYPE_BLANK,
+		Title:         http.StatusText(er.StatusCode),
+		Status:        er.StatusCode,
+		Instance:      er.Where,
+		RequestID:     er.RequestID,
+		DetailedError: er.DetailedError,
+	}
+	if er.ErrorCode != "" && er.ErrorCode != "error" {
+		p.Type = PROBLEM_TYPE_PREFIX + er.ErrorCode
+	}
+
+	for i, errMap := range er.Errors {
+		fields := make([]string, 0, len(errMap))
+		for field := range errMap {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		for _, field := range fields {
+			var errs []*Error
+			switch v := errMap[field].(type) {
+			case *Error:
+				errs = []*Error{v}
+			case []*Error:
+				errs = v
+			}
+
+			if field == "detail" {
+				if p.Detail == "" && len(errs) > 0 {
+					p.Detail = errs[0].message
+				}
+				continue
+			}
+
+			for _, err := range errs {
+				fieldErr := &ProblemFieldError{Field: field, Detail: err.message}
+				if er.Many {
+					index := i
+					fieldErr.Index = &index
+				}
+				p.Errors = append(p.Errors, fieldErr)
+			}
+		}
+	}
+
+	return p
+}
+
+// ToProblemJSON convert a AppError to an RFC 7807 problem details json string
+func (er *AppError) ToProblemJSON() string {
+	b, _ := json.Marshal(er.ToProblem())
+	return string(b)
+}
+
 // AppErrorFromJSON will decode the input and return an AppError
 func AppErrorFromJSON(data io.Reader) *AppError {
 	var er struct {
 		Data struct {
 			Detail string `json:"detail"`
 		} `json:"data"`
-		Error string `json:"error"`
+		Error         string `json:"error"`
+		RequestID     string `json:"request_id"`
+		DetailedError string `json:"detailed_error"`
 	}
 
 	err := json.NewDecoder(data).Decode(&er)
-	if err == nil {
-		return NewAppError("", "", nil, er.Data.Detail, statusCode[er.Error])
+	if err != nil {
+		return NewAppError("AppErrorFromJSON", "model.utils.decode_json.app_error", nil, err.Error(), http.StatusInternalServerError)
 	}
-	return NewAppError("AppErrorFromJSON", "model.utils.decode_json.app_error", nil, err.Error(), http.StatusInternalServerError)
+
+	ap := &AppError{
+		Errors:        []map[string]interface{}{{"detail": []*Error{{message: er.Data.Detail}}}},
+		ErrorCode:     er.Error,
+		Message:       er.Data.Detail,
+		DetailedError: er.DetailedError,
+		RequestID:     er.RequestID,
+	}
+	if kind := ErrorKindFromCode(er.Error); kind != nil {
+		ap.Kind = kind
+		ap.StatusCode = kind.Status
+	} else {
+		ap.ErrorCode = "error"
+		ap.StatusCode = statusCodeFromText(er.Error)
+	}
+	return ap
+}
+
+// AppErrorFromProblemJSON will decode RFC 7807 problem details and return an AppError
+func AppErrorFromProblemJSON(data io.Reader) *AppError {
+	var p Problem
+	if err := json.NewDecoder(data).Decode(&p); err != nil {
+		return NewAppError("AppErrorFromProblemJSON", "model.utils.decode_json.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	ap := &AppError{
+		Errors:        []map[string]interface{}{{"detail": []*Error{{message: p.Detail}}}},
+		ErrorCode:     "error",
+		Message:       p.Detail,
+		StatusCode:    p.Status,
+		Where:         p.Instance,
+		DetailedError: p.DetailedError,
+		RequestID:     p.RequestID,
+	}
+	if strings.HasPrefix(p.Type, PROBLEM_TYPE_PREFIX) {
+		if kind := ErrorKindFromCode(strings.TrimPrefix(p.Type, PROBLEM_TYPE_PREFIX)); kind != nil {
+			ap.Kind = kind
+			ap.ErrorCode = kind.Code
+		}
+	}
+	return ap
 }
 
-// InvalidFieldError creates new invalid field error
-func InvalidFieldError(tableName, fieldName string, id interface{}) *AppError {
-	msgID := fmt.Sprintf("model.%s.is_valid.%s.app_error", tableName, fieldName)
-	details := ""
-	if id != nil {
-		details = fmt.Sprintf("id=%v", id)
+// statusCodeFromText returns the HTTP status code with the given text, or 500 if there is none.
+func statusCodeFromText(text string) int {
+	for code := 100; code < 600; code++ {
+		if http.StatusText(code) == text {
+			return code
+		}
 	}
-	return ValidationError("InvalidFieldError", msgID, nil, details)
+	return http.StatusInternalServerError
 }
 
 // ValidationError creates new validation error
-func ValidationError(where, message string, params map[string]interface{}, details string) *AppError {
-	if len(message) == 0 {
-		message = "Invalid input"
+func ValidationError(where, id string, params map[string]interface{}, details string) *AppError {
+	if len(id) == 0 {
+		id = ErrValidation.ID
 	}
-	return NewAppErrorWithCode(where, message, params, details, "ValidationError", http.StatusBadRequest)
+	return ErrValidation.NewWithID(where, id, params, details)
 }
 
 // ValidationErrorWithDetails creates new validation error
 func ValidationErrorWithDetails(where string, errors []map[string]interface{}) *AppError {
-	return NewAppErrorWithDetails(where, errors, false, "ValidationError", http.StatusBadRequest)
+	ap := NewAppErrorWithDetails(where, errors, false, ErrValidation.Code, ErrValidation.Status)
+	ap.Kind = ErrValidation
+	return ap
 }
 
 // ValidationErrorWithManyDetails creates new validation error
 func ValidationErrorWithManyDetails(where string, errors []map[string]interface{}) *AppError {
-	return NewAppErrorWithDetails(where, errors, true, "ValidationError", http.StatusBadRequest)
+	ap := NewAppErrorWithDetails(where, errors, true, ErrValidation.Code, ErrValidation.Status)
+	ap.Kind = ErrValidation
+	return ap
 }
 
 // ParseError creates new parse error
 func ParseError(where, id string, params map[string]interface{}, details string) *AppError {
-	return NewAppErrorWithCode(where, id, nil, details, "ParseError", http.StatusBadRequest)
-}
-
-// UnauthorizedError creates new authorization failed error
-func UnauthorizedError(where, details string) *AppError {
-	return NewAppErrorWithCode(where, "model.app_error.unauthorized", nil, details, "Unauthorized", http.StatusUnauthorized)
-}
-
-// AuthenticationFailedError creates new authentication failed error
-func AuthenticationFailedError(where, details string) *AppError {
-	return NewAppErrorWithCode(where, "model.app_error.authentication_failed", nil, details, "AuthenticationFailed", http.StatusUnauthorized)
-}
-
-// AuthenticationFailedCustomError creates new custom authentication failed error
-func AuthenticationFailedCustomError(where, message string, params map[string]interface{}, details string) *AppError {
-	return NewAppErrorWithCode(where, message, params, details, "AuthenticationFailed", http.StatusUnauthorized)
+	return ErrParse.NewWithID(where, id, params, details)
 }
 
 // NotAuthenticatedError creates new not authenticated error
 func NotAuthenticatedError(where, details string) *AppError {
-	return NewAppErrorWithCode(where, "model.app_error.not_authenticated", nil, details, "NotAuthenticated", http.StatusUnauthorized)
+	return ErrNotAuthenticated.New(where, nil, details)
 }
 
 // PermissionDeniedError creates new permission denied error
 func PermissionDeniedError(where, details string) *AppError {
-	return NewAppErrorWithCode(where, "model.app_error.permission_denied", nil, details, "PermissionDenied", http.StatusForbidden)
+	return ErrPermissionDenied.New(where, nil, details)
 }
 
 // NotFoundError creates new not found error
 func NotFoundError(where, details string) *AppError {
-	return NewAppErrorWithCode(where, "model.app_error.not_found", nil, details, "NotFound", http.StatusNotFound)
-}
-
-// ExpiredTokenError creates new token expired error
-func ExpiredTokenError(where, details string) *AppError {
-	return NewAppErrorWithCode(where, "model.app_error.expired_token", nil, details, "ExpiredTokenError", http.StatusBadRequest)
-}
-
-// InvalidTokenError creates new invalid token error
-func InvalidTokenError(where, details string) *AppError {
-	return NewAppErrorWithCode(where, "model.app_error.invalid_token", nil, details, "InvalidTokenError", http.StatusBadRequest)
-}
-
-// InvalidAuthenticationTokenError creates new invalid token error
-func InvalidAuthenticationTokenError(where, details string) *AppError {
-	return NewAppErrorWithCode(where, "model.app_error.invalid_authentication_token", nil, details, "InvalidAuthenticationTokenError", http.StatusBadRequest)
-}
-
-// ExpiredAuthenticationTokenError creates new invalid token error
-func ExpiredAuthenticationTokenError(where, details string) *AppError {
-	return NewAppErrorWithCode(where, "model.app_error.expired_authentication_token", nil, details, "InvalidAuthenticationTokenError", http.StatusBadRequest)
-}
-
-// InvalidAuthenticationTokenError creates new invalid token error
-func InvalidSmsUpdateTokenError(where, details string) *AppError {
-	return NewAppErrorWithCode(where, "model.app_error.invalid_token", nil, details, "InvalidSmsUpdateTokenError", http.StatusBadRequest)
-}
-
-// InvalidAuthenticationTokenError creates new invalid token error
-func InvalidEmailUpdateTokenError(where, details string) *AppError {
-	return NewAppErrorWithCode(where, "model.app_error.invalid_token", nil, details, "InvalidEmailUpdateTokenError", http.StatusBadRequest)
-}
-
-// LoginUserNotFound creates new user not found error
-func LoginUserNotFound(where, details string) *AppError {
-	return NewAppErrorWithCode(where, "model.app_error.login_user_not_found", nil, details, "LoginUserNotFound", http.StatusBadRequest)
+	return ErrNotFound.New(where, nil, details)
 }
 
 // ParamNotFoundError creates new param not found error
 func ParamNotFoundError(where, parameter string) *AppError {
-	return NewSimpleAppError(where, "api.context.param.not_found", map[string]interface{}{"Param": parameter}, http.StatusBadRequest)
+	return ValidationError(where, "api.context.param.not_found", map[string]interface{}{"Param": parameter}, "")
 }
 
 // InvalidParamError creates new invalid request param error
@@ -295,13 +432,3 @@ func InvalidRequestBodyError() *AppError {
 func NewInvalidUrlParamError(parameter string) *AppError {
 	return ValidationError("", "api.context.invalid_url_param", map[string]interface{}{"Name": parameter}, "")
 }
-
-// SameCurrentNoteError creates new same current note error
-func SameCurrentNoteError(where, details string) *AppError {
-	return ValidationError(where, "model.app_error.same_current_note", nil, details)
-}
-
-// SameCurrentTaskError create new same current task error
-func SameCurrentTaskError(where, details string) *AppError {
-	return ValidationError(where, "model.app_error.same_current_task", nil, details)
-}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"net/http"
+)
+
+// ErrorKind is an entry of the error catalog. Every AppError created from a kind carries the
+// kind's machine code as ErrorCode, which is the "error" field of the response body, and its
+// HTTP status. Codes are stable, so clients can branch on them.
+//
+// An AppError unwraps to its kind, so errors.Is(err, ErrSnippetNotFound) reports whether err
+// is a snippet-not-found error, and errors.As(err, &kind) recovers the kind of any error.
+type ErrorKind struct {
+	Code   string
+	Status int
+	ID     string // Translation ID of the default message
+}
+
+// The error catalog, by code and HTTP status:
+//
+//	ValidationError      400  a parameter or the request body is invalid
+//	ParseError           400  the request body is not valid JSON
+//	NotAuthenticated     401  credentials are missing or invalid
+//	PermissionDenied     403  the credentials do not allow the operation
+//	NotFound             404  the requested resource does not exist
+//	SnippetNotFound      404  no snippet has the requested name
+//	SnippetNameConflict  409  a live snippet already has the requested name
+//	SnippetExpired       410  the snippet has expired and will soon be removed
+//	SnippetTooLarge      413  the snippet body or an attachment exceeds the size limit
+//	RateLimited          429  the client sent too many requests, and should retry later
+//	Unavailable          503  the service cannot handle the request right now
+//
+// Errors outside the catalog, such as internal errors, have the code "error" and are rendered
+// with the text of their HTTP status.
+var (
+	ErrValidation          = &ErrorKind{Code: "ValidationError", Status: http.StatusBadRequest, ID: "model.app_error.validation"}
+	ErrParse               = &ErrorKind{Code: "ParseError", Status: http.StatusBadRequest, ID: "api.request.malformed"}
+	ErrNotAuthenticated    = &ErrorKind{Code: "NotAuthenticated", Status: http.StatusUnauthorized, ID: "model.app_error.not_authenticated"}
+	ErrPermissionDenied    = &ErrorKind{Code: "PermissionDenied", Status: http.StatusForbidden, ID: "model.app_error.permission_denied"}
+	ErrNotFound            = &ErrorKind{Code: "NotFound", Status: http.StatusNotFound, ID: "model.app_error.not_found"}
+	ErrSnippetNotFound     = &ErrorKind{Code: "SnippetNotFound", Status: http.StatusNotFound, ID: "model.app_error.snippet_not_found"}
+	ErrSnippetNameConflict = &ErrorKind{Code: "SnippetNameConflict", Status: http.StatusConflict, ID: "model.app_error.snippet_name_conflict"}
+	ErrSnippetExpired      = &ErrorKind{Code: "SnippetExpired", Status: http.StatusGone, ID: "model.app_error.snippet_expired"}
+	ErrSnippetTooLarge     = &ErrorKind{Code: "SnippetTooLarge", Status: http.StatusRequestEntityTooLarge, ID: "model.app_error.snippet_too_large"}
+	ErrRateLimited         = &ErrorKind{Code: "RateLimited", Status: http.StatusTooManyRequests, ID: "model.app_error.rate_limited"}
+	ErrUnavailable         = &ErrorKind{Code: "Unavailable", Status: http.StatusServiceUnavailable, ID: "model.app_error.unavailable"}
+)
+
+var errorKinds = []*ErrorKind{
+	ErrValidation,
+	ErrParse,
+	ErrNotAuthenticated,
+	ErrPermissionDenied,
+	ErrNotFound,
+	ErrSnippetNotFound,
+	ErrSnippetNameConflict,
+	ErrSnippetExpired,
+	ErrSnippetTooLarge,
+	ErrRateLimited,
+	ErrUnavailable,
+}
+
+// ErrorKinds returns the error catalog
+func ErrorKinds() []*ErrorKind {
+	return append([]*ErrorKind(nil), errorKinds...)
+}
+
+// ErrorKindFromCode returns the kind with the given machine code, or nil if there is none.
+func ErrorKindFromCode(code string) *ErrorKind {
+	for _, kind := range errorKinds {
+		if kind.Code == code {
+			return kind
+		}
+	}
+	return nil
+}
+
+func (k *ErrorKind) Error() string {
+	return k.Code
+}
+
+// New creates an AppError of the kind with its default message
+func (k *ErrorKind) New(where string, params map[string]interface{}, details string) *AppError {
+	return k.NewWithID(where, k.ID, params, details)
+}
+
+// NewWithID creates an AppError of the kind with a more specific message
+func (k *ErrorKind) NewWithID(where, id string, params map[string]interface{}, details string) *AppError {
+	ap := NewAppErrorWithCode(where, id, params, details, k.Code, k.Status)
+	ap.Kind = k
+	return ap
+}
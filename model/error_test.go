@@ -0,0 +1,117 @@
+package model
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// translateID translates messages to their id, so that tests can tell them apart.
+func translateID(translationID string, params map[string]interface{}) string {
+	return translationID
+}
+
+func TestAppErrorToProblem(t *testing.T) {
+	t.Run("kinds", func(t *testing.T) {
+		for _, kind := range ErrorKinds() {
+			t.Run(kind.Code, func(t *testing.T) {
+				err := kind.New("SnippetStore.Get", nil, "details")
+				err.Translate(translateID)
+
+				assert.Equal(t, &Problem{
+					Type:          PROBLEM_TYPE_PREFIX + kind.Code,
+					Title:         http.StatusText(kind.Status),
+					Status:        kind.Status,
+					Detail:        kind.ID,
+					Instance:      "SnippetStore.Get",
+					DetailedError: "details",
+				}, err.ToProblem())
+			})
+		}
+	})
+
+	t.Run("without kind", func(t *testing.T) {
+		err := NewAppError("reloadConfig", "api.config.reload_config.app_error", nil, "", http.StatusInternalServerError)
+		err.RequestID = "request"
+		err.Translate(translateID)
+
+		assert.Equal(t, &Problem{
+			Type:      PROBLEM_TYPE_BLANK,
+			Title:     "Internal Server Error",
+			Status:    http.StatusInternalServerError,
+			Detail:    "api.config.reload_config.app_error",
+			Instance:  "reloadConfig",
+			RequestID: "request",
+		}, err.ToProblem())
+	})
+
+	t.Run("custom code", func(t *testing.T) {
+		err := NewAppErrorWithCode("where", "id", nil, "", "CustomError", http.StatusTeapot)
+		assert.Equal(t, PROBLEM_TYPE_PREFIX+"CustomError", err.ToProblem().Type)
+	})
+
+	t.Run("field errors", func(t *testing.T) {
+		err := ValidationErrorWithDetails("createSnippet", []map[string]interface{}{{
+			"name":       []*Error{NewError("model.snippet.name.required", nil)},
+			"expires_in": NewError("model.snippet.expires_in.invalid", nil),
+			"detail":     []*Error{NewError("model.app_error.validation", nil)},
+		}})
+		err.Translate(translateID)
+
+		p := err.ToProblem()
+		assert.Equal(t, PROBLEM_TYPE_PREFIX+ErrValidation.Code, p.Type)
+		assert.Equal(t, "model.app_error.validation", p.Detail)
+		assert.Equal(t, []*ProblemFieldError{
+			{Field: "expires_in", Detail: "model.snippet.expires_in.invalid"},
+			{Field: "name", Detail: "model.snippet.name.required"},
+		}, p.Errors, "sorted by field, without index")
+	})
+
+	t.Run("field errors of many items", func(t *testing.T) {
+		err := ValidationErrorWithManyDetails("createSnippets", []map[string]interface{}{
+			{},
+			{"name": []*Error{NewError("model.snippet.name.required", nil), NewError("model.snippet.name.invalid", nil)}},
+			{"body": []*Error{NewError("model.snippet.body.required", nil)}},
+		})
+		err.Translate(translateID)
+
+		index := func(i int) *int { return &i }
+		assert.Equal(t, []*ProblemFieldError{
+			{Field: "name", Index: index(1), Detail: "model.snippet.name.required"},
+			{Field: "name", Index: index(1), Detail: "model.snippet.name.invalid"},
+			{Field: "body", Index: index(2), Detail: "model.snippet.body.required"},
+		}, err.ToProblem().Errors)
+	})
+}
+
+func TestAppErrorToProblemJSON(t *testing.T) {
+	err := ValidationErrorWithManyDetails("createSnippets", []map[string]interface{}{
+		{"name": []*Error{NewError("model.snippet.name.required", nil)}},
+	})
+	err.Where = "/api/v1/snippets"
+	err.RequestID = "request"
+	err.Translate(translateID)
+
+	assert.JSONEq(t, `{
+		"type": "urn:snippet-challenge:error:ValidationError",
+		"title": "Bad Request",
+		"status": 400,
+		"instance": "/api/v1/snippets",
+		"request_id": "request",
+		"errors": [{"field": "name", "index": 0, "detail": "model.snippet.name.required"}]
+	}`, err.ToProblemJSON())
+
+	t.Run("round trip", func(t *testing.T) {
+		kindErr := ErrSnippetNotFound.New("getSnippet", nil, "")
+		kindErr.Translate(translateID)
+
+		decoded := AppErrorFromProblemJSON(strings.NewReader(kindErr.ToProblemJSON()))
+		require.NotNil(t, decoded)
+		assert.Same(t, ErrSnippetNotFound, decoded.Kind)
+		assert.Equal(t, http.StatusNotFound, decoded.StatusCode)
+		assert.Equal(t, ErrSnippetNotFound.ID, decoded.Message)
+	})
+}
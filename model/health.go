@@ -0,0 +1,22 @@
+package model
+
+import (
+	"encoding/json"
+)
+
+// ReadinessReport structure
+type ReadinessReport struct {
+	Status       string            `json:"status"`
+	Dependencies map[string]string `json:"dependencies"`
+}
+
+// IsReady reports whether every dependency is healthy
+func (o *ReadinessReport) IsReady() bool {
+	return o.Status == STATUS_OK
+}
+
+// ToJSON convert a ReadinessReport to a json string
+func (o *ReadinessReport) ToJSON() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"encoding/json"
+)
+
+// RuntimeStats structure
+type RuntimeStats struct {
+	GoVersion  string            `json:"go_version"`
+	NumCPU     int               `json:"num_cpu"`
+	Goroutines int               `json:"goroutines"`
+	Uptime     int64             `json:"uptime_seconds"`
+	Memory     RuntimeMemStats   `json:"memory"`
+	GC         RuntimeGCStats    `json:"gc"`
+	Store      RuntimeStoreStats `json:"store"`
+}
+
+// RuntimeMemStats is the subset of runtime.MemStats worth watching while profiling
+type RuntimeMemStats struct {
+	Alloc        uint64 `json:"alloc"`
+	TotalAlloc   uint64 `json:"total_alloc"`
+	Sys          uint64 `json:"sys"`
+	Mallocs      uint64 `json:"mallocs"`
+	Frees        uint64 `json:"frees"`
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapIdle     uint64 `json:"heap_idle"`
+	HeapReleased uint64 `json:"heap_released"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	StackInuse   uint64 `json:"stack_inuse"`
+}
+
+// RuntimeGCStats structure
+type RuntimeGCStats struct {
+	NumGC        uint32  `json:"num_gc"`
+	NumForcedGC  uint32  `json:"num_forced_gc"`
+	PauseTotalNs uint64  `json:"pause_total_ns"`
+	LastPauseNs  uint64  `json:"last_pause_ns"`
+	LastGC       int64   `json:"last_gc"`
+	NextGC       uint64  `json:"next_gc"`
+	CPUFraction  float64 `json:"cpu_fraction"`
+}
+
+// RuntimeStoreStats structure
+type RuntimeStoreStats struct {
+	Snippets     int   `json:"snippets"`
+	SnippetBytes int64 `json:"snippet_bytes"`
+}
+
+// ToJSON convert a RuntimeStats to a json string
+func (o *RuntimeStats) ToJSON() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
@@ -3,17 +3,32 @@ package model
 import (
 	"encoding/json"
 	"io"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/topoface/snippet-challenge/mlog"
 )
 
+const (
+	SNIPPET_DEFAULT_EXPIRES_IN = 30                 // seconds
+	SNIPPET_MAX_EXPIRES_IN     = 365 * 24 * 60 * 60 // seconds
+	SNIPPET_EXPIRY_EXTENSION   = 30 * time.Second
+
+	SNIPPET_QUERY_DEFAULT_PER_PAGE = 60
+	SNIPPET_QUERY_MAX_PER_PAGE     = 200
+
+	SNIPPET_ATTACHMENT_NAME_MAX_LENGTH = 255
+)
+
 // Snippet structure
 type Snippet struct {
-	URL       string    `json:"url"`
-	Name      string    `json:"name"`
-	ExpiresAt time.Time `json:"expires_at"`
-	Body      string    `json:"snippet"`
+	ID          string               `json:"-"` // Identifies the files of the snippet, as names are reused once snippets expire
+	URL         string               `json:"url"`
+	Name        string               `json:"name"`
+	ExpiresAt   time.Time            `json:"expires_at"`
+	Body        string               `json:"snippet"`
+	Attachments []*SnippetAttachment `json:"attachments,omitempty"`
 }
 
 func SnippetFromJSON(data io.Reader) *Snippet {
@@ -30,9 +45,218 @@ func (o *Snippet) ToJSON() string {
 	return string(b)
 }
 
+// Clone returns a copy of the snippet that can be changed without affecting the original.
+// Attachments are never changed once created, so they are shared.
+func (o *Snippet) Clone() *Snippet {
+	clone := *o
+	if o.Attachments != nil {
+		clone.Attachments = append([]*SnippetAttachment(nil), o.Attachments...)
+	}
+	return &clone
+}
+
+// GetAttachment returns the attachment with the given name, or nil if there is none.
+func (o *Snippet) GetAttachment(name string) *SnippetAttachment {
+	for _, attachment := range o.Attachments {
+		if attachment.Name == name {
+			return attachment
+		}
+	}
+	return nil
+}
+
+// SnippetListToJSON convert a list of Snippet to a json string
+func SnippetListToJSON(snippets []*Snippet) string {
+	if snippets == nil {
+		snippets = []*Snippet{}
+	}
+	b, _ := json.Marshal(snippets)
+	return string(b)
+}
+
+// IsExpired reports whether the snippet has expired at the given time
+func (o *Snippet) IsExpired(now time.Time) bool {
+	return !o.ExpiresAt.After(now)
+}
+
 // SnippetRequest structure
 type SnippetRequest struct {
 	Name      string `json:"name" validate:"blank:false;required"`
 	ExpiresIn uint64 `json:"expires_in"`
 	Body      string `json:"snippet" validate:"blank:false;required"`
 }
+
+// IsValid validates the snippet request
+func (o *SnippetRequest) IsValid() *AppError {
+	if !IsValidSnippetName(o.Name) {
+		return InvalidParamError("name")
+	}
+
+	if o.ExpiresIn > SNIPPET_MAX_EXPIRES_IN {
+		return InvalidParamError("expires_in")
+	}
+
+	if strings.TrimSpace(o.Body) == "" {
+		return InvalidParamError("snippet")
+	}
+
+	return nil
+}
+
+// SnippetPatch changes the body or the expiry of a snippet. Fields left out are kept as they are.
+type SnippetPatch struct {
+	Body      *string `json:"snippet"`
+	ExpiresIn *uint64 `json:"expires_in"` // Seconds from now, like in SnippetRequest
+}
+
+func (o *SnippetPatch) ToJSON() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+// IsValid validates the snippet patch
+func (o *SnippetPatch) IsValid() *AppError {
+	if o.Body != nil && strings.TrimSpace(*o.Body) == "" {
+		return InvalidParamError("snippet")
+	}
+
+	if o.ExpiresIn != nil && *o.ExpiresIn > SNIPPET_MAX_EXPIRES_IN {
+		return InvalidParamError("expires_in")
+	}
+
+	return nil
+}
+
+// Apply changes the snippet as requested by the patch, counting a new expiry from now.
+func (o *SnippetPatch) Apply(snippet *Snippet, now time.Time) {
+	if o.Body != nil {
+		snippet.Body = *o.Body
+	}
+
+	if o.ExpiresIn != nil {
+		expiresIn := *o.ExpiresIn
+		if expiresIn == 0 {
+			expiresIn = SNIPPET_DEFAULT_EXPIRES_IN
+		}
+		snippet.ExpiresAt = now.Add(time.Duration(expiresIn) * time.Second)
+	}
+}
+
+// SnippetQuery selects a page of the live snippets, sorted by name.
+type SnippetQuery struct {
+	Page    int
+	PerPage int
+}
+
+// SnippetAttachment describes a file attached to a snippet. Attachments are removed along with
+// their snippet.
+type SnippetAttachment struct {
+	ID          string `json:"-"` // Identifies the stored file, which is replaced on every upload
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	CreateAt    int64  `json:"create_at"`
+}
+
+func (o *SnippetAttachment) ToJSON() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+// SnippetAttachmentListToJSON convert a list of SnippetAttachment to a json string
+func SnippetAttachmentListToJSON(attachments []*SnippetAttachment) string {
+	if attachments == nil {
+		attachments = []*SnippetAttachment{}
+	}
+	b, _ := json.Marshal(attachments)
+	return string(b)
+}
+
+// IsValidSnippetName reports whether the name can be used for a snippet, which rules out names
+// that can't be requested as a single segment of the path of its URL.
+func IsValidSnippetName(name string) bool {
+	if strings.TrimSpace(name) == "" || name == "." || name == ".." {
+		return false
+	}
+
+	for _, r := range name {
+		if r == '/' || unicode.IsControl(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsValidSnippetAttachmentName reports whether the name can be used for an attachment, which
+// rules out anything that could be taken for a path.
+func IsValidSnippetAttachmentName(name string) bool {
+	if strings.TrimSpace(name) == "" || len(name) > SNIPPET_ATTACHMENT_NAME_MAX_LENGTH || name == "." || name == ".." {
+		return false
+	}
+
+	for _, r := range name {
+		if r == '/' || r == '\\' || unicode.IsControl(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SnippetV2 is the representation of a snippet in the v2 API, which names the body content and
+// gives the expiry in milliseconds like the rest of the API.
+type SnippetV2 struct {
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	Content   string `json:"content"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// ToV2 converts the snippet to its v2 representation, served at the given URL.
+func (o *Snippet) ToV2(url string) *SnippetV2 {
+	return &SnippetV2{
+		Name:      o.Name,
+		URL:       url,
+		Content:   o.Body,
+		ExpiresAt: o.ExpiresAt.UnixNano() / int64(time.Millisecond),
+	}
+}
+
+func (o *SnippetV2) ToJSON() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+// SnippetRequestV2 structure
+type SnippetRequestV2 struct {
+	Name      string `json:"name" validate:"blank:false;required"`
+	ExpiresIn uint64 `json:"expires_in"`
+	Content   string `json:"content" validate:"blank:false;required"`
+}
+
+// IsValid validates the snippet request
+func (o *SnippetRequestV2) IsValid() *AppError {
+	if !IsValidSnippetName(o.Name) {
+		return InvalidParamError("name")
+	}
+
+	if o.ExpiresIn > SNIPPET_MAX_EXPIRES_IN {
+		return InvalidParamError("expires_in")
+	}
+
+	if strings.TrimSpace(o.Content) == "" {
+		return InvalidParamError("content")
+	}
+
+	return nil
+}
+
+// ToV1 converts the request to a SnippetRequest, which the app layer handles for both versions.
+func (o *SnippetRequestV2) ToV1() *SnippetRequest {
+	return &SnippetRequest{
+		Name:      o.Name,
+		ExpiresIn: o.ExpiresIn,
+		Body:      o.Content,
+	}
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnippetPatchApply(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(time.Minute)
+
+	for name, tc := range map[string]struct {
+		Patch     *SnippetPatch
+		Body      string
+		ExpiresAt time.Time
+	}{
+		"empty":           {&SnippetPatch{}, "body", expiresAt},
+		"body":            {&SnippetPatch{Body: NewString("changed")}, "changed", expiresAt},
+		"expiry":          {&SnippetPatch{ExpiresIn: NewUint64(10)}, "body", now.Add(10 * time.Second)},
+		"default expiry":  {&SnippetPatch{ExpiresIn: NewUint64(0)}, "body", now.Add(SNIPPET_DEFAULT_EXPIRES_IN * time.Second)},
+		"body and expiry": {&SnippetPatch{Body: NewString("changed"), ExpiresIn: NewUint64(10)}, "changed", now.Add(10 * time.Second)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			snippet := &Snippet{Body: "body", ExpiresAt: expiresAt}
+			tc.Patch.Apply(snippet, now)
+
+			assert.Equal(t, tc.Body, snippet.Body)
+			assert.True(t, tc.ExpiresAt.Equal(snippet.ExpiresAt))
+		})
+	}
+
+	assert.NotNil(t, (&SnippetPatch{Body: NewString(" ")}).IsValid())
+	assert.Nil(t, (&SnippetPatch{}).IsValid())
+}
+
+func TestIsValidSnippetAttachmentName(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"notes.txt":              true,
+		"photo of the cake.png":  true,
+		".hidden":                true,
+		"":                       false,
+		" ":                      false,
+		".":                      false,
+		"..":                     false,
+		"a/b":                    false,
+		`a\b`:                    false,
+		"line\nbreak":            false,
+		strings.Repeat("a", 255): true,
+		strings.Repeat("a", 256): false,
+	} {
+		assert.Equal(t, valid, IsValidSnippetAttachmentName(name), "%q", name)
+	}
+}
+
+func TestSnippetRequestIsValid(t *testing.T) {
+	for name, tc := range map[string]struct {
+		Request *SnippetRequest
+		Valid   bool
+	}{
+		"valid":              {&SnippetRequest{Name: "recipe", Body: "body"}, true},
+		"query characters":   {&SnippetRequest{Name: "what? #1", Body: "body"}, true},
+		"max expiry":         {&SnippetRequest{Name: "recipe", Body: "body", ExpiresIn: SNIPPET_MAX_EXPIRES_IN}, true},
+		"blank name":         {&SnippetRequest{Name: " ", Body: "body"}, false},
+		"slash in name":      {&SnippetRequest{Name: "a/b", Body: "body"}, false},
+		"dot segment":        {&SnippetRequest{Name: "..", Body: "body"}, false},
+		"control in name":    {&SnippetRequest{Name: "line\nbreak", Body: "body"}, false},
+		"blank body":         {&SnippetRequest{Name: "recipe", Body: " "}, false},
+		"expiry above max":   {&SnippetRequest{Name: "recipe", Body: "body", ExpiresIn: SNIPPET_MAX_EXPIRES_IN + 1}, false},
+		"overflowing expiry": {&SnippetRequest{Name: "recipe", Body: "body", ExpiresIn: math.MaxUint64}, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.Valid, tc.Request.IsValid() == nil)
+
+			v2 := &SnippetRequestV2{Name: tc.Request.Name, ExpiresIn: tc.Request.ExpiresIn, Content: tc.Request.Body}
+			assert.Equal(t, tc.Valid, v2.IsValid() == nil, "v2")
+		})
+	}
+
+	assert.Nil(t, (&SnippetPatch{ExpiresIn: NewUint64(SNIPPET_MAX_EXPIRES_IN)}).IsValid())
+	assert.NotNil(t, (&SnippetPatch{ExpiresIn: NewUint64(math.MaxUint64)}).IsValid())
+}
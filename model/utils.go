@@ -14,7 +14,7 @@ import (
 	uuid "github.com/satori/go.uuid"
 )
 
-var encoding = base32.NewEncoding("gxma8d698zf0cww77nhbg8jj1zv4sk8g")
+var encoding = base32.NewEncoding("ybndrfg8ejkmcpqxot1uwisza345h769")
 
 // NewID is a globally unique identifier.
 // It is generated by UUID version 4 and encoded with base58 with the [-] removed.
@@ -81,6 +81,12 @@ func MapToJSON(objmap map[string]string) string {
 	return string(b)
 }
 
+// StringInterfaceToJSON converts a map with interface values to a json string
+func StringInterfaceToJSON(objmap map[string]interface{}) string {
+	b, _ := json.Marshal(objmap)
+	return string(b)
+}
+
 // MapFromJSON will decode the key/value pair map
 func MapFromJSON(data io.Reader) map[string]string {
 	decoder := json.NewDecoder(data)
@@ -13,6 +13,8 @@ type ReadCloseSeeker interface {
 }
 
 type FileBackend interface {
+	TestConnection() *model.AppError
+
 	FileExists(path string) (bool, *model.AppError)
 	ReadFile(path string) ([]byte, *model.AppError)
 	WriteFile(fr io.ReadSeeker, size int64, path string) (int64, *model.AppError)
@@ -18,6 +18,16 @@ type LocalFileBackend struct {
 	directory string
 }
 
+// TestConnection checks that the storage directory exists, creating it as WriteFile would if it
+// does not. Unlike writing a test file, this only stats an existing directory, so that it is cheap
+// enough for readiness probes.
+func (b *LocalFileBackend) TestConnection() *model.AppError {
+	if err := os.MkdirAll(b.directory, 0750); err != nil {
+		return model.NewAppError("TestFileConnection", "services.file.test_connection.local.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	return nil
+}
+
 func (b *LocalFileBackend) ReadFile(path string) ([]byte, *model.AppError) {
 	f, err := ioutil.ReadFile(filepath.Join(b.directory, path))
 	if err != nil {
@@ -0,0 +1,90 @@
+package filestore
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/topoface/snippet-challenge/model"
+	"github.com/topoface/snippet-challenge/services/tracing"
+)
+
+// tracedFileBackend records a child span of ctx around every call to the wrapped backend.
+type tracedFileBackend struct {
+	ctx     context.Context
+	backend FileBackend
+}
+
+// NewTracedFileBackend wraps the backend so that its calls are traced as children of the span carried by ctx.
+func NewTracedFileBackend(ctx context.Context, backend FileBackend) FileBackend {
+	return &tracedFileBackend{
+		ctx:     ctx,
+		backend: backend,
+	}
+}
+
+func (b *tracedFileBackend) startSpan(method, path string) *tracing.Span {
+	_, span := tracing.StartSpan(b.ctx, "FileBackend."+method)
+	if path != "" {
+		span.SetAttribute("file.path", path)
+	}
+	return span
+}
+
+func endSpan(span *tracing.Span, err *model.AppError) {
+	if err != nil {
+		span.SetError(err)
+	}
+	span.End()
+}
+
+func (b *tracedFileBackend) TestConnection() *model.AppError {
+	span := b.startSpan("TestConnection", "")
+	err := b.backend.TestConnection()
+	endSpan(span, err)
+	return err
+}
+
+func (b *tracedFileBackend) FileExists(path string) (bool, *model.AppError) {
+	span := b.startSpan("FileExists", path)
+	exists, err := b.backend.FileExists(path)
+	endSpan(span, err)
+	return exists, err
+}
+
+func (b *tracedFileBackend) ReadFile(path string) ([]byte, *model.AppError) {
+	span := b.startSpan("ReadFile", path)
+	data, err := b.backend.ReadFile(path)
+	span.SetAttribute("file.size", len(data))
+	endSpan(span, err)
+	return data, err
+}
+
+func (b *tracedFileBackend) WriteFile(fr io.ReadSeeker, size int64, path string) (int64, *model.AppError) {
+	span := b.startSpan("WriteFile", path)
+	written, err := b.backend.WriteFile(fr, size, path)
+	span.SetAttribute("file.size", written)
+	endSpan(span, err)
+	return written, err
+}
+
+func (b *tracedFileBackend) RemoveFile(path string) *model.AppError {
+	span := b.startSpan("RemoveFile", path)
+	err := b.backend.RemoveFile(path)
+	endSpan(span, err)
+	return err
+}
+
+func (b *tracedFileBackend) RemoveDirectory(path string) *model.AppError {
+	span := b.startSpan("RemoveDirectory", path)
+	err := b.backend.RemoveDirectory(path)
+	endSpan(span, err)
+	return err
+}
+
+func (b *tracedFileBackend) GetSignedFileURL(path string, expire time.Time) (*string, *model.AppError) {
+	span := b.startSpan("GetSignedFileURL", path)
+	signedURL, err := b.backend.GetSignedFileURL(path, expire)
+	endSpan(span, err)
+	return signedURL, err
+}
@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	MetricsNamespace = "snippetserver"
+
+	MetricsSubsystemHTTP     = "http"
+	MetricsSubsystemSnippets = "snippets"
+	MetricsSubsystemStore    = "store"
+	MetricsSubsystemConfig   = "config"
+)
+
+// SnippetStatsFunc reports the number of live snippets and the bytes they hold.
+type SnippetStatsFunc func() (count int, bytes int64)
+
+// Metrics holds the Prometheus collectors exported by the server.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequestsTotal    *prometheus.CounterVec
+	httpRequestDuration  *prometheus.HistogramVec
+	httpRequestsInFlight prometheus.Gauge
+
+	snippetsCreatedTotal prometheus.Counter
+	snippetsReadTotal    prometheus.Counter
+	snippetsExpiredTotal prometheus.Counter
+
+	storeMethodDuration *prometheus.HistogramVec
+
+	configReloadsTotal prometheus.Counter
+}
+
+// New creates the collectors and registers them, together with the Go runtime and process
+// collectors, in a dedicated registry.
+func New(snippetStats SnippetStatsFunc) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+	}
+
+	m.registry.MustRegister(prometheus.NewGoCollector())
+	m.registry.MustRegister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+
+	m.httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystemHTTP,
+		Name:      "requests_total",
+		Help:      "The total number of HTTP requests per handler.",
+	}, []string{"handler", "method", "code"})
+	m.registry.MustRegister(m.httpRequestsTotal)
+
+	m.httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystemHTTP,
+		Name:      "request_duration_seconds",
+		Help:      "The latency of HTTP requests per handler.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"handler", "method"})
+	m.registry.MustRegister(m.httpRequestDuration)
+
+	m.httpRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystemHTTP,
+		Name:      "requests_in_flight",
+		Help:      "The number of HTTP requests currently being served.",
+	})
+	m.registry.MustRegister(m.httpRequestsInFlight)
+
+	m.snippetsCreatedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystemSnippets,
+		Name:      "created_total",
+		Help:      "The total number of snippets created.",
+	})
+	m.registry.MustRegister(m.snippetsCreatedTotal)
+
+	m.snippetsReadTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystemSnippets,
+		Name:      "read_total",
+		Help:      "The total number of snippet reads.",
+	})
+	m.registry.MustRegister(m.snippetsReadTotal)
+
+	m.snippetsExpiredTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystemSnippets,
+		Name:      "expired_total",
+		Help:      "The total number of expired snippets removed from the store.",
+	})
+	m.registry.MustRegister(m.snippetsExpiredTotal)
+
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystemSnippets,
+		Name:      "live",
+		Help:      "The number of snippets that have not expired.",
+	}, func() float64 {
+		count, _ := snippetStats()
+		return float64(count)
+	}))
+
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystemSnippets,
+		Name:      "stored_bytes",
+		Help:      "The number of bytes held by snippets that have not expired.",
+	}, func() float64 {
+		_, bytes := snippetStats()
+		return float64(bytes)
+	}))
+
+	m.storeMethodDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystemStore,
+		Name:      "method_duration_seconds",
+		Help:      "The latency of store methods.",
+		Buckets:   []float64{0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1},
+	}, []string{"method", "success"})
+	m.registry.MustRegister(m.storeMethodDuration)
+
+	m.configReloadsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystemConfig,
+		Name:      "reloads_total",
+		Help:      "The total number of times the configuration was reloaded or saved.",
+	})
+	m.registry.MustRegister(m.configReloadsTotal)
+
+	return m
+}
+
+// Handler serves the collected metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) IncrementHTTPRequestsInFlight() {
+	m.httpRequestsInFlight.Inc()
+}
+
+func (m *Metrics) DecrementHTTPRequestsInFlight() {
+	m.httpRequestsInFlight.Dec()
+}
+
+func (m *Metrics) ObserveHTTPRequest(handler, method string, statusCode int, elapsed float64) {
+	m.httpRequestsTotal.WithLabelValues(handler, method, strconv.Itoa(statusCode)).Inc()
+	m.httpRequestDuration.WithLabelValues(handler, method).Observe(elapsed)
+}
+
+func (m *Metrics) IncrementSnippetsCreated() {
+	m.snippetsCreatedTotal.Inc()
+}
+
+func (m *Metrics) IncrementSnippetsRead() {
+	m.snippetsReadTotal.Inc()
+}
+
+func (m *Metrics) AddSnippetsExpired(count int) {
+	m.snippetsExpiredTotal.Add(float64(count))
+}
+
+func (m *Metrics) ObserveStoreMethodDuration(method string, success bool, elapsed float64) {
+	m.storeMethodDuration.WithLabelValues(method, strconv.FormatBool(success)).Observe(elapsed)
+}
+
+func (m *Metrics) IncrementConfigReloads() {
+	m.configReloadsTotal.Inc()
+}
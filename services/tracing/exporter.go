@@ -0,0 +1,230 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Exporter sends finished spans to a tracing backend.
+type Exporter interface {
+	ExportSpans(spans []*SpanData) error
+	Shutdown() error
+}
+
+// writerExporter writes every span as a line of JSON.
+type writerExporter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	closer io.Closer
+}
+
+// NewStdoutExporter creates an exporter writing spans as JSON lines to stdout.
+func NewStdoutExporter() Exporter {
+	return &writerExporter{writer: os.Stdout}
+}
+
+// NewFileExporter creates an exporter appending spans as JSON lines to the given file.
+func NewFileExporter(path string) (Exporter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, errors.Wrapf(err, "failed to create directory for %s", path)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", path)
+	}
+
+	return &writerExporter{writer: file, closer: file}, nil
+}
+
+func (e *writerExporter) ExportSpans(spans []*SpanData) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	encoder := json.NewEncoder(e.writer)
+	for _, span := range spans {
+		if err := encoder.Encode(span); err != nil {
+			return errors.Wrap(err, "failed to write span")
+		}
+	}
+
+	return nil
+}
+
+func (e *writerExporter) Shutdown() error {
+	if e.closer == nil {
+		return nil
+	}
+	return e.closer.Close()
+}
+
+// otlpExporter posts spans to an OpenTelemetry collector using OTLP/HTTP with JSON encoding.
+type otlpExporter struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+}
+
+// NewOTLPExporter creates an exporter posting spans to the OTLP/HTTP traces endpoint of a
+// collector, for example http://localhost:4318/v1/traces.
+func NewOTLPExporter(endpoint, serviceName string) Exporter {
+	return &otlpExporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+func (e *otlpExporter) ExportSpans(spans []*SpanData) error {
+	scopeSpans := otlpScopeSpans{}
+	scopeSpans.Scope.Name = "github.com/topoface/snippet-challenge/services/tracing"
+	for _, span := range spans {
+		scopeSpans.Spans = append(scopeSpans.Spans, toOTLPSpan(span))
+	}
+
+	resourceSpans := otlpResourceSpans{ScopeSpans: []otlpScopeSpans{scopeSpans}}
+	resourceSpans.Resource.Attributes = []otlpKeyValue{toOTLPKeyValue("service.name", e.serviceName)}
+
+	request := otlpRequest{ResourceSpans: []otlpResourceSpans{resourceSpans}}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode spans")
+	}
+
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "failed to post spans to %s", e.endpoint)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector at %s responded with status %d", e.endpoint, resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (e *otlpExporter) Shutdown() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+func toOTLPSpan(span *SpanData) otlpSpan {
+	result := otlpSpan{
+		TraceID:           span.TraceID,
+		SpanID:            span.SpanID,
+		ParentSpanID:      span.ParentSpanID,
+		Name:              span.Name,
+		Kind:              1, // SPAN_KIND_INTERNAL
+		StartTimeUnixNano: strconv.FormatInt(span.StartTime.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.EndTime.UnixNano(), 10),
+	}
+
+	if span.ParentSpanID == "" {
+		result.Kind = 2 // SPAN_KIND_SERVER
+	}
+
+	switch span.Status {
+	case StatusOK:
+		result.Status.Code = 1
+	case StatusError:
+		result.Status.Code = 2
+		result.Status.Message = span.StatusMessage
+	}
+
+	keys := make([]string, 0, len(span.Attributes))
+	for key := range span.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		result.Attributes = append(result.Attributes, toOTLPKeyValue(key, span.Attributes[key]))
+	}
+
+	return result
+}
+
+func toOTLPKeyValue(key string, value interface{}) otlpKeyValue {
+	kv := otlpKeyValue{Key: key}
+
+	switch v := value.(type) {
+	case string:
+		kv.Value.StringValue = &v
+	case bool:
+		kv.Value.BoolValue = &v
+	case int:
+		s := strconv.Itoa(v)
+		kv.Value.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		kv.Value.IntValue = &s
+	case float64:
+		kv.Value.DoubleValue = &v
+	default:
+		s := fmt.Sprint(v)
+		kv.Value.StringValue = &s
+	}
+
+	return kv
+}
@@ -0,0 +1,184 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToOTLPKeyValue(t *testing.T) {
+	for name, tc := range map[string]struct {
+		Value    interface{}
+		Expected string
+	}{
+		"string":  {"GET", `{"key":"k","value":{"stringValue":"GET"}}`},
+		"empty":   {"", `{"key":"k","value":{"stringValue":""}}`},
+		"true":    {true, `{"key":"k","value":{"boolValue":true}}`},
+		"false":   {false, `{"key":"k","value":{"boolValue":false}}`},
+		"int":     {200, `{"key":"k","value":{"intValue":"200"}}`},
+		"int64":   {int64(1) << 40, `{"key":"k","value":{"intValue":"1099511627776"}}`},
+		"float64": {0.5, `{"key":"k","value":{"doubleValue":0.5}}`},
+		"other":   {[]string{"a"}, `{"key":"k","value":{"stringValue":"[a]"}}`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			b, err := json.Marshal(toOTLPKeyValue("k", tc.Value))
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.Expected, string(b))
+		})
+	}
+}
+
+func TestToOTLPSpan(t *testing.T) {
+	start := time.Unix(1600000000, 123456789)
+
+	span := &SpanData{
+		TraceID:      "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:       "00f067aa0ba902b7",
+		ParentSpanID: "b7ad6b7169203331",
+		Name:         "SqlStore.Get",
+		StartTime:    start,
+		EndTime:      start.Add(time.Millisecond),
+		Attributes: map[string]interface{}{
+			"http.status_code": 500,
+			"http.method":      "GET",
+		},
+		Status:        StatusError,
+		StatusMessage: "Internal Server Error",
+	}
+
+	b, err := json.Marshal(toOTLPSpan(span))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"traceId": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"spanId": "00f067aa0ba902b7",
+		"parentSpanId": "b7ad6b7169203331",
+		"name": "SqlStore.Get",
+		"kind": 1,
+		"startTimeUnixNano": "1600000000123456789",
+		"endTimeUnixNano": "1600000000124456789",
+		"attributes": [
+			{"key": "http.method", "value": {"stringValue": "GET"}},
+			{"key": "http.status_code", "value": {"intValue": "500"}}
+		],
+		"status": {"code": 2, "message": "Internal Server Error"}
+	}`, string(b))
+
+	t.Run("root", func(t *testing.T) {
+		root := *span
+		root.ParentSpanID = ""
+		root.Attributes = nil
+		root.Status = StatusOK
+
+		b, err := json.Marshal(toOTLPSpan(&root))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"traceId": "4bf92f3577b34da6a3ce929d0e0e4736",
+			"spanId": "00f067aa0ba902b7",
+			"name": "SqlStore.Get",
+			"kind": 2,
+			"startTimeUnixNano": "1600000000123456789",
+			"endTimeUnixNano": "1600000000124456789",
+			"status": {"code": 1}
+		}`, string(b))
+	})
+
+	t.Run("unset status", func(t *testing.T) {
+		unset := *span
+		unset.Status = StatusUnset
+
+		assert.Equal(t, otlpStatus{}, toOTLPSpan(&unset).Status)
+	})
+}
+
+func TestOTLPExporter(t *testing.T) {
+	span := &SpanData{
+		TraceID:   "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:    "00f067aa0ba902b7",
+		Name:      "createSnippet",
+		StartTime: time.Unix(1600000000, 0),
+		EndTime:   time.Unix(1600000001, 0),
+		Status:    StatusOK,
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var received []byte
+		collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "/v1/traces", r.URL.Path)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			received, _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer collector.Close()
+
+		exporter := NewOTLPExporter(collector.URL+"/v1/traces", "snippet-test")
+		require.NoError(t, exporter.ExportSpans([]*SpanData{span, span}))
+		require.NoError(t, exporter.Shutdown())
+
+		var request struct {
+			ResourceSpans []struct {
+				Resource struct {
+					Attributes []otlpKeyValue `json:"attributes"`
+				} `json:"resource"`
+				ScopeSpans []struct {
+					Scope struct {
+						Name string `json:"name"`
+					} `json:"scope"`
+					Spans []map[string]interface{} `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		require.NoError(t, json.Unmarshal(received, &request))
+
+		require.Len(t, request.ResourceSpans, 1)
+		resource := request.ResourceSpans[0]
+		require.Len(t, resource.Resource.Attributes, 1)
+		assert.Equal(t, "service.name", resource.Resource.Attributes[0].Key)
+		assert.Equal(t, "snippet-test", *resource.Resource.Attributes[0].Value.StringValue)
+
+		require.Len(t, resource.ScopeSpans, 1)
+		assert.NotEmpty(t, resource.ScopeSpans[0].Scope.Name)
+		require.Len(t, resource.ScopeSpans[0].Spans, 2)
+		assert.Equal(t, "createSnippet", resource.ScopeSpans[0].Spans[0]["name"])
+	})
+
+	t.Run("collector error", func(t *testing.T) {
+		collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+		}))
+		defer collector.Close()
+
+		err := NewOTLPExporter(collector.URL, "snippet-test").ExportSpans([]*SpanData{span})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "503")
+	})
+
+	t.Run("collector unreachable", func(t *testing.T) {
+		collector := httptest.NewServer(http.NotFoundHandler())
+		collector.Close()
+
+		err := NewOTLPExporter(collector.URL, "snippet-test").ExportSpans([]*SpanData{span})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), collector.URL)
+	})
+
+	t.Run("tracer survives export errors", func(t *testing.T) {
+		requests := make(chan struct{}, 10)
+		collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests <- struct{}{}
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer collector.Close()
+
+		tracer := NewTracer(NewOTLPExporter(collector.URL, "snippet-test"))
+		tracer.enqueue(span)
+		require.NoError(t, tracer.Shutdown(), "queued spans are flushed on shutdown")
+		assert.Len(t, requests, 1)
+	})
+}
@@ -0,0 +1,123 @@
+package tracing
+
+import (
+	"sync"
+	"time"
+
+	"github.com/topoface/snippet-challenge/mlog"
+)
+
+const (
+	batchSize     = 256
+	queueSize     = 2048
+	flushInterval = 2 * time.Second
+)
+
+var (
+	globalTracer     *Tracer
+	globalTracerLock sync.RWMutex
+)
+
+// GetTracer returns the tracer used by StartSpan, or nil while tracing is disabled.
+func GetTracer() *Tracer {
+	globalTracerLock.RLock()
+	defer globalTracerLock.RUnlock()
+
+	return globalTracer
+}
+
+// SetTracer replaces the tracer used by StartSpan and returns the previous one, which the
+// caller is responsible for shutting down. Passing nil disables tracing.
+func SetTracer(tracer *Tracer) *Tracer {
+	globalTracerLock.Lock()
+	defer globalTracerLock.Unlock()
+
+	previous := globalTracer
+	globalTracer = tracer
+	return previous
+}
+
+// Tracer batches finished spans and hands them to an exporter from a background goroutine.
+type Tracer struct {
+	exporter Exporter
+
+	queue   chan *SpanData
+	stop    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// NewTracer creates a tracer exporting through the given exporter.
+func NewTracer(exporter Exporter) *Tracer {
+	t := &Tracer{
+		exporter: exporter,
+		queue:    make(chan *SpanData, queueSize),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+
+	go t.run()
+
+	return t
+}
+
+// enqueue hands a finished span to the export goroutine, dropping it if the queue is full so
+// that a slow exporter never blocks request handling.
+func (t *Tracer) enqueue(span *SpanData) {
+	select {
+	case t.queue <- span:
+	default:
+		mlog.Debug("Dropped span, export queue is full", mlog.String("span", span.Name))
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.stopped)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*SpanData, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.exporter.ExportSpans(batch); err != nil {
+			mlog.Warn("Failed to export spans", mlog.Int("count", len(batch)), mlog.Err(err))
+		}
+		batch = make([]*SpanData, 0, batchSize)
+	}
+
+	for {
+		select {
+		case span := <-t.queue:
+			batch = append(batch, span)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.stop:
+			for {
+				select {
+				case span := <-t.queue:
+					batch = append(batch, span)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Shutdown exports the queued spans and releases the exporter.
+func (t *Tracer) Shutdown() error {
+	var err error
+	t.once.Do(func() {
+		close(t.stop)
+		<-t.stopped
+		err = t.exporter.Shutdown()
+	})
+	return err
+}
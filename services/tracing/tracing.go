@@ -0,0 +1,211 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUnset = "UNSET"
+	StatusOK    = "OK"
+	StatusError = "ERROR"
+
+	// HeaderTraceParent is the W3C Trace Context header used to propagate traces between services.
+	HeaderTraceParent = "traceparent"
+)
+
+type contextKey struct{}
+
+// SpanData is the immutable record of a finished span handed to exporters.
+type SpanData struct {
+	TraceID       string                 `json:"trace_id"`
+	SpanID        string                 `json:"span_id"`
+	ParentSpanID  string                 `json:"parent_span_id,omitempty"`
+	Name          string                 `json:"name"`
+	StartTime     time.Time              `json:"start_time"`
+	EndTime       time.Time              `json:"end_time"`
+	Attributes    map[string]interface{} `json:"attributes,omitempty"`
+	Status        string                 `json:"status"`
+	StatusMessage string                 `json:"status_message,omitempty"`
+}
+
+// Span is a single timed operation within a trace. A nil *Span is a valid no-op span, which is
+// what StartSpan returns while tracing is disabled.
+type Span struct {
+	tracer *Tracer
+
+	mutex sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// TraceID returns the hex encoded trace ID, or an empty string for a no-op span.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return s.data.TraceID
+}
+
+// SpanID returns the hex encoded span ID, or an empty string for a no-op span.
+func (s *Span) SpanID() string {
+	if s == nil {
+		return ""
+	}
+	return s.data.SpanID
+}
+
+// SetAttribute records a key/value pair describing the operation.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(map[string]interface{})
+	}
+	s.data.Attributes[key] = value
+}
+
+// SetError marks the span as failed when err is not nil, and as successful otherwise.
+func (s *Span) SetError(err error) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err != nil {
+		s.data.Status = StatusError
+		s.data.StatusMessage = err.Error()
+	} else if s.data.Status == StatusUnset {
+		s.data.Status = StatusOK
+	}
+}
+
+// End finishes the span and queues it for export. Calling End more than once has no effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = time.Now()
+	data := s.data
+	s.mutex.Unlock()
+
+	s.tracer.enqueue(&data)
+}
+
+// ContextWithSpan returns a copy of ctx carrying the span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, contextKey{}, span)
+}
+
+// SpanFromContext returns the span carried by ctx, or nil if there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(contextKey{}).(*Span)
+	return span
+}
+
+// StartSpan starts a span with the global tracer. The span is a child of the span carried by
+// ctx, if any, and the returned context carries the new span.
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	tracer := GetTracer()
+	if tracer == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		tracer: tracer,
+		data: SpanData{
+			SpanID:    newID(8),
+			Name:      name,
+			StartTime: time.Now(),
+			Status:    StatusUnset,
+		},
+	}
+
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.data.TraceID = parent.data.TraceID
+		span.data.ParentSpanID = parent.data.SpanID
+	} else if remote, ok := ctx.Value(remoteParentKey{}).(remoteParent); ok {
+		span.data.TraceID = remote.traceID
+		span.data.ParentSpanID = remote.spanID
+	} else {
+		span.data.TraceID = newID(16)
+	}
+
+	return ContextWithSpan(ctx, span), span
+}
+
+type remoteParentKey struct{}
+
+type remoteParent struct {
+	traceID string
+	spanID  string
+}
+
+// ContextWithTraceParent returns a copy of ctx under which StartSpan continues the trace
+// described by a W3C traceparent header value. Malformed values are ignored.
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) != 4 || parts[0] != "00" || !isHexID(parts[1], 16) || !isHexID(parts[2], 8) {
+		return ctx
+	}
+
+	return context.WithValue(ctx, remoteParentKey{}, remoteParent{
+		traceID: parts[1],
+		spanID:  parts[2],
+	})
+}
+
+// TraceParent formats the span as a W3C traceparent header value.
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", s.data.TraceID, s.data.SpanID)
+}
+
+func isHexID(id string, size int) bool {
+	b, err := hex.DecodeString(id)
+	if err != nil || len(b) != size {
+		return false
+	}
+
+	// An all zero ID is invalid according to the W3C Trace Context specification.
+	for _, c := range b {
+		if c != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func newID(size int) string {
+	b := make([]byte, size)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,156 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingExporter keeps the exported spans in memory.
+type recordingExporter struct {
+	mutex sync.Mutex
+	spans []*SpanData
+}
+
+func (e *recordingExporter) ExportSpans(spans []*SpanData) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *recordingExporter) Shutdown() error {
+	return nil
+}
+
+// setupTracer makes StartSpan record spans until the end of the test. The recorded spans are
+// complete once the returned tracer is shut down.
+func setupTracer(t *testing.T) (*Tracer, *recordingExporter) {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(exporter)
+
+	previous := SetTracer(tracer)
+	t.Cleanup(func() {
+		SetTracer(previous)
+		tracer.Shutdown()
+	})
+
+	return tracer, exporter
+}
+
+func TestStartSpan(t *testing.T) {
+	tracer, exporter := setupTracer(t)
+
+	ctx, root := StartSpan(context.Background(), "root")
+	require.NotNil(t, root)
+	assert.Same(t, root, SpanFromContext(ctx))
+	assert.True(t, isHexID(root.TraceID(), 16))
+	assert.True(t, isHexID(root.SpanID(), 8))
+
+	childCtx, child := StartSpan(ctx, "child")
+	assert.Same(t, child, SpanFromContext(childCtx))
+	assert.Equal(t, root.TraceID(), child.TraceID())
+	assert.NotEqual(t, root.SpanID(), child.SpanID())
+
+	_, sibling := StartSpan(ctx, "sibling")
+	assert.Equal(t, root.TraceID(), sibling.TraceID())
+	assert.NotEqual(t, child.SpanID(), sibling.SpanID())
+
+	_, other := StartSpan(context.Background(), "other")
+	assert.NotEqual(t, root.TraceID(), other.TraceID())
+
+	child.SetAttribute("count", 2)
+	child.SetError(errors.New("failed"))
+	child.SetError(nil)
+	child.End()
+	child.End()
+	sibling.SetError(nil)
+	sibling.End()
+	root.End()
+
+	require.NoError(t, tracer.Shutdown())
+	require.Len(t, exporter.spans, 3, "ending a span twice exports it once")
+
+	spans := map[string]*SpanData{}
+	for _, span := range exporter.spans {
+		spans[span.Name] = span
+	}
+
+	assert.Empty(t, spans["root"].ParentSpanID)
+	assert.Equal(t, StatusUnset, spans["root"].Status)
+
+	assert.Equal(t, root.SpanID(), spans["child"].ParentSpanID)
+	assert.Equal(t, StatusError, spans["child"].Status, "a later success does not clear an error")
+	assert.Equal(t, "failed", spans["child"].StatusMessage)
+	assert.Equal(t, map[string]interface{}{"count": 2}, spans["child"].Attributes)
+	assert.False(t, spans["child"].EndTime.Before(spans["child"].StartTime))
+
+	assert.Equal(t, root.SpanID(), spans["sibling"].ParentSpanID)
+	assert.Equal(t, StatusOK, spans["sibling"].Status)
+}
+
+func TestStartSpanDisabled(t *testing.T) {
+	previous := SetTracer(nil)
+	defer SetTracer(previous)
+
+	ctx := context.Background()
+	spanCtx, span := StartSpan(ctx, "disabled")
+	assert.Nil(t, span)
+	assert.Equal(t, ctx, spanCtx)
+
+	// A nil span is a no-op.
+	span.SetAttribute("key", "value")
+	span.SetError(errors.New("failed"))
+	span.End()
+	assert.Empty(t, span.TraceID())
+	assert.Empty(t, span.SpanID())
+	assert.Empty(t, span.TraceParent())
+}
+
+func TestContextWithTraceParent(t *testing.T) {
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+
+	t.Run("valid", func(t *testing.T) {
+		setupTracer(t)
+
+		ctx := ContextWithTraceParent(context.Background(), " 00-"+traceID+"-"+spanID+"-01 ")
+		ctx, span := StartSpan(ctx, "remote child")
+		assert.Equal(t, traceID, span.TraceID())
+		assert.Equal(t, spanID, span.data.ParentSpanID)
+		assert.Equal(t, "00-"+traceID+"-"+span.SpanID()+"-01", span.TraceParent())
+
+		// Local children descend from the local span rather than the remote parent.
+		_, child := StartSpan(ctx, "local child")
+		assert.Equal(t, traceID, child.TraceID())
+		assert.Equal(t, span.SpanID(), child.data.ParentSpanID)
+	})
+
+	for name, traceParent := range map[string]string{
+		"empty":             "",
+		"unknown version":   "01-" + traceID + "-" + spanID + "-01",
+		"missing flags":     "00-" + traceID + "-" + spanID,
+		"extra field":       "00-" + traceID + "-" + spanID + "-01-00",
+		"short trace id":    "00-" + traceID[2:] + "-" + spanID + "-01",
+		"short span id":     "00-" + traceID + "-" + spanID[2:] + "-01",
+		"non hex trace id":  "00-" + "zz" + traceID[2:] + "-" + spanID + "-01",
+		"all zero trace id": "00-00000000000000000000000000000000-" + spanID + "-01",
+		"all zero span id":  "00-" + traceID + "-0000000000000000-01",
+	} {
+		t.Run(name, func(t *testing.T) {
+			setupTracer(t)
+
+			ctx := ContextWithTraceParent(context.Background(), traceParent)
+			_, span := StartSpan(ctx, "root")
+			assert.NotEqual(t, traceID, span.TraceID())
+			assert.Empty(t, span.data.ParentSpanID)
+		})
+	}
+}
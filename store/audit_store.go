@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"sync"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+// auditStoreMaxRecords bounds the records kept in memory for the audit API. The audit log file,
+// enabled by AuditSettings.FileEnabled, is the complete record.
+const auditStoreMaxRecords = 10000
+
+// AuditStore structure
+type AuditStore struct {
+	*Store
+
+	mutex  sync.RWMutex
+	audits []*model.Audit // oldest first
+}
+
+func newAuditStore(Store *Store) *AuditStore {
+	s := &AuditStore{
+		Store: Store,
+	}
+
+	return s
+}
+
+// Save appends a new audit record, dropping the oldest one once auditStoreMaxRecords are kept.
+// Records cannot be changed once saved.
+func (as *AuditStore) Save(ctx context.Context, audit *model.Audit) (appErr *model.AppError) {
+	defer as.startMethod(ctx, "AuditStore.Save")(&appErr)
+
+	if err := as.beginWrite("AuditStore.Save"); err != nil {
+		return err
+	}
+	defer as.endWrite()
+
+	saved := *audit
+	saved.PreSave()
+
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+
+	as.audits = append(as.audits, &saved)
+	if len(as.audits) > auditStoreMaxRecords {
+		as.audits[0] = nil
+		as.audits = as.audits[1:]
+	}
+
+	*audit = saved
+	return nil
+}
+
+// Query returns a page of the audit records matching the query, newest first.
+func (as *AuditStore) Query(ctx context.Context, query *model.AuditQuery) (_ []*model.Audit, appErr *model.AppError) {
+	defer as.startMethod(ctx, "AuditStore.Query")(&appErr)
+
+	as.mutex.RLock()
+	defer as.mutex.RUnlock()
+
+	skip := query.Page * query.PerPage
+	audits := []*model.Audit{}
+	for i := len(as.audits) - 1; i >= 0 && len(audits) < query.PerPage; i-- {
+		if !query.Matches(as.audits[i]) {
+			continue
+		}
+
+		if skip > 0 {
+			skip--
+			continue
+		}
+
+		audit := *as.audits[i]
+		audits = append(audits, &audit)
+	}
+
+	return audits, nil
+}
+
+// PermanentDeleteBefore removes the audit records created before the given time in milliseconds,
+// as they fall out of the retention period, and returns how many were removed.
+func (as *AuditStore) PermanentDeleteBefore(before int64) int {
+	defer as.startMethod(context.Background(), "AuditStore.PermanentDeleteBefore")(nil)
+
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+
+	kept := make([]*model.Audit, 0, len(as.audits))
+	for _, audit := range as.audits {
+		if audit.CreateAt >= before {
+			kept = append(kept, audit)
+		}
+	}
+
+	count := len(as.audits) - len(kept)
+	as.audits = kept
+
+	return count
+}
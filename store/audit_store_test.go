@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+func TestAuditStore(t *testing.T) {
+	as := NewStore().Audit()
+	ctx := context.Background()
+
+	for i, audit := range []*model.Audit{
+		{Event: "createSnippet", Target: "a", Status: model.AUDIT_STATUS_SUCCESS, CreateAt: 1000},
+		{Event: "createSnippet", Target: "b", Status: model.AUDIT_STATUS_FAIL, CreateAt: 2000},
+		{Event: "patchConfig", Target: "config", Status: model.AUDIT_STATUS_SUCCESS, CreateAt: 3000},
+	} {
+		require.Nil(t, as.Save(ctx, audit), "audit %d", i)
+		assert.NotEmpty(t, audit.ID)
+	}
+
+	audits, err := as.Query(ctx, &model.AuditQuery{PerPage: 10})
+	require.Nil(t, err)
+	require.Len(t, audits, 3)
+	assert.Equal(t, "patchConfig", audits[0].Event, "newest first")
+
+	audits, err = as.Query(ctx, &model.AuditQuery{Event: "createSnippet", PerPage: 1, Page: 1})
+	require.Nil(t, err)
+	require.Len(t, audits, 1)
+	assert.Equal(t, "a", audits[0].Target)
+
+	audits, err = as.Query(ctx, &model.AuditQuery{Since: 2000, Until: 2000, PerPage: 10})
+	require.Nil(t, err)
+	require.Len(t, audits, 1)
+	assert.Equal(t, "b", audits[0].Target)
+
+	// Returned records are copies, so they cannot alter the trail.
+	audits[0].Status = model.AUDIT_STATUS_SUCCESS
+	audits, err = as.Query(ctx, &model.AuditQuery{Target: "b", PerPage: 10})
+	require.Nil(t, err)
+	assert.Equal(t, model.AUDIT_STATUS_FAIL, audits[0].Status)
+
+	assert.Equal(t, 2, as.PermanentDeleteBefore(3000))
+	audits, err = as.Query(ctx, &model.AuditQuery{PerPage: 10})
+	require.Nil(t, err)
+	require.Len(t, audits, 1)
+	assert.Equal(t, "patchConfig", audits[0].Event)
+}
+
+func TestAuditStoreMaxRecords(t *testing.T) {
+	as := NewStore().Audit()
+	ctx := context.Background()
+
+	for i := 0; i < auditStoreMaxRecords+2; i++ {
+		require.Nil(t, as.Save(ctx, &model.Audit{Event: "createSnippet", Target: strconv.Itoa(i)}))
+	}
+
+	assert.Len(t, as.audits, auditStoreMaxRecords)
+	assert.Equal(t, "2", as.audits[0].Target, "the oldest records are dropped")
+	assert.Equal(t, strconv.Itoa(auditStoreMaxRecords+1), as.audits[len(as.audits)-1].Target)
+}
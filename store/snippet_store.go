@@ -1,21 +1,287 @@
 package store
 
-import "github.com/topoface/snippet-challenge/model"
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/topoface/snippet-challenge/model"
+)
 
 // SnippetStore structure
 type SnippetStore struct {
 	*Store
+
+	mutex    sync.RWMutex
+	snippets map[string]*model.Snippet
 }
 
 func newSnippetStore(Store *Store) *SnippetStore {
 	s := &SnippetStore{
-		Store,
+		Store:    Store,
+		snippets: make(map[string]*model.Snippet),
 	}
 
 	return s
 }
 
-// Create creates a new snippet
-func (ss SnippetStore) Create(snippet *model.Snippet) (*model.Snippet, *model.AppError) {
-	return nil, nil
+// Save stores a new snippet. It fails if a live snippet with the same name already exists, and
+// returns the expired snippet it replaced, if any, which DeleteExpired will no longer return.
+func (ss *SnippetStore) Save(ctx context.Context, snippet *model.Snippet) (expired *model.Snippet, appErr *model.AppError) {
+	defer ss.startMethod(ctx, "SnippetStore.Save")(&appErr)
+
+	if err := ss.beginWrite("SnippetStore.Save"); err != nil {
+		return nil, err
+	}
+	defer ss.endWrite()
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	existing, ok := ss.snippets[snippet.Name]
+	if ok && !existing.IsExpired(time.Now()) {
+		return nil, model.ErrSnippetNameConflict.New("SnippetStore.Save", map[string]interface{}{"Name": snippet.Name}, "")
+	}
+
+	ss.snippets[snippet.Name] = snippet.Clone()
+
+	return existing, nil
+}
+
+// Get returns the live snippet with the given name.
+func (ss *SnippetStore) Get(ctx context.Context, name string) (_ *model.Snippet, appErr *model.AppError) {
+	defer ss.startMethod(ctx, "SnippetStore.Get")(&appErr)
+
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	snippet, err := ss.getLive("SnippetStore.Get", name, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return snippet.Clone(), nil
+}
+
+// GetPage returns a page of the live snippets, sorted by name.
+func (ss *SnippetStore) GetPage(ctx context.Context, page, perPage int) (_ []*model.Snippet, appErr *model.AppError) {
+	defer ss.startMethod(ctx, "SnippetStore.GetPage")(&appErr)
+
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	now := time.Now()
+	names := make([]string, 0, len(ss.snippets))
+	for name, snippet := range ss.snippets {
+		if !snippet.IsExpired(now) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	start := page * perPage
+	if start >= len(names) {
+		return []*model.Snippet{}, nil
+	}
+	end := start + perPage
+	if end > len(names) {
+		end = len(names)
+	}
+
+	snippets := make([]*model.Snippet, 0, end-start)
+	for _, name := range names[start:end] {
+		snippets = append(snippets, ss.snippets[name].Clone())
+	}
+
+	return snippets, nil
+}
+
+// Touch extends the expiry of the live snippet with the given name and returns the updated snippet.
+func (ss *SnippetStore) Touch(ctx context.Context, name string, extension time.Duration) (_ *model.Snippet, appErr *model.AppError) {
+	defer ss.startMethod(ctx, "SnippetStore.Touch")(&appErr)
+
+	if err := ss.beginWrite("SnippetStore.Touch"); err != nil {
+		return nil, err
+	}
+	defer ss.endWrite()
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	snippet, err := ss.getLive("SnippetStore.Touch", name, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	snippet.ExpiresAt = snippet.ExpiresAt.Add(extension)
+
+	return snippet.Clone(), nil
+}
+
+// Patch changes the live snippet with the given name as requested by the patch and returns the
+// updated snippet.
+func (ss *SnippetStore) Patch(ctx context.Context, name string, patch *model.SnippetPatch) (_ *model.Snippet, appErr *model.AppError) {
+	defer ss.startMethod(ctx, "SnippetStore.Patch")(&appErr)
+
+	if err := ss.beginWrite("SnippetStore.Patch"); err != nil {
+		return nil, err
+	}
+	defer ss.endWrite()
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	now := time.Now()
+	snippet, err := ss.getLive("SnippetStore.Patch", name, now)
+	if err != nil {
+		return nil, err
+	}
+
+	patch.Apply(snippet, now)
+
+	return snippet.Clone(), nil
+}
+
+// Delete removes the live snippet with the given name and returns it.
+func (ss *SnippetStore) Delete(ctx context.Context, name string) (_ *model.Snippet, appErr *model.AppError) {
+	defer ss.startMethod(ctx, "SnippetStore.Delete")(&appErr)
+
+	if err := ss.beginWrite("SnippetStore.Delete"); err != nil {
+		return nil, err
+	}
+	defer ss.endWrite()
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	snippet, err := ss.getLive("SnippetStore.Delete", name, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	delete(ss.snippets, name)
+
+	return snippet, nil
+}
+
+// SaveAttachment adds the attachment to the live snippet with the given name and id, replacing
+// the attachment with the same name if any, which is returned. The id makes sure that the
+// attachment is not added to another snippet created with the name since its file was stored.
+func (ss *SnippetStore) SaveAttachment(ctx context.Context, name, snippetID string, attachment *model.SnippetAttachment) (_ *model.SnippetAttachment, appErr *model.AppError) {
+	defer ss.startMethod(ctx, "SnippetStore.SaveAttachment")(&appErr)
+
+	if err := ss.beginWrite("SnippetStore.SaveAttachment"); err != nil {
+		return nil, err
+	}
+	defer ss.endWrite()
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	snippet, err := ss.getLive("SnippetStore.SaveAttachment", name, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if snippet.ID != snippetID {
+		return nil, model.ErrSnippetNotFound.New("SnippetStore.SaveAttachment", map[string]interface{}{"Name": name}, "id="+snippetID)
+	}
+
+	// The attachments are shared with the clones returned so far, so the list is replaced rather
+	// than changed in place.
+	attachments := make([]*model.SnippetAttachment, 0, len(snippet.Attachments)+1)
+	var replaced *model.SnippetAttachment
+	for _, existing := range snippet.Attachments {
+		if existing.Name == attachment.Name {
+			replaced = existing
+			continue
+		}
+		attachments = append(attachments, existing)
+	}
+	snippet.Attachments = append(attachments, attachment)
+
+	return replaced, nil
+}
+
+// DeleteAttachment removes the attachment with the given name from the live snippet with the
+// given name, and returns the snippet as it was before.
+func (ss *SnippetStore) DeleteAttachment(ctx context.Context, name, attachmentName string) (_ *model.Snippet, appErr *model.AppError) {
+	defer ss.startMethod(ctx, "SnippetStore.DeleteAttachment")(&appErr)
+
+	if err := ss.beginWrite("SnippetStore.DeleteAttachment"); err != nil {
+		return nil, err
+	}
+	defer ss.endWrite()
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	snippet, err := ss.getLive("SnippetStore.DeleteAttachment", name, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if snippet.GetAttachment(attachmentName) == nil {
+		return nil, model.ErrNotFound.NewWithID("SnippetStore.DeleteAttachment", "store.snippet.attachment_not_found.app_error", map[string]interface{}{"Name": attachmentName}, "")
+	}
+
+	result := snippet.Clone()
+
+	attachments := make([]*model.SnippetAttachment, 0, len(snippet.Attachments)-1)
+	for _, existing := range snippet.Attachments {
+		if existing.Name != attachmentName {
+			attachments = append(attachments, existing)
+		}
+	}
+	snippet.Attachments = attachments
+
+	return result, nil
+}
+
+// getLive returns the snippet with the given name, failing if it has expired but has not been
+// removed yet. The caller must hold the mutex.
+func (ss *SnippetStore) getLive(where, name string, now time.Time) (*model.Snippet, *model.AppError) {
+	snippet, ok := ss.snippets[name]
+	if !ok {
+		return nil, model.ErrSnippetNotFound.New(where, map[string]interface{}{"Name": name}, "")
+	}
+
+	if snippet.IsExpired(now) {
+		return nil, model.ErrSnippetExpired.New(where, map[string]interface{}{"Name": name}, "")
+	}
+
+	return snippet, nil
+}
+
+// DeleteExpired removes every snippet that expired before the given time and returns the removed snippets.
+func (ss *SnippetStore) DeleteExpired(now time.Time) []*model.Snippet {
+	defer ss.startMethod(context.Background(), "SnippetStore.DeleteExpired")(nil)
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	var removed []*model.Snippet
+	for name, snippet := range ss.snippets {
+		if snippet.IsExpired(now) {
+			delete(ss.snippets, name)
+			removed = append(removed, snippet)
+		}
+	}
+
+	return removed
+}
+
+// Stats returns the number of live snippets and the total size of their bodies in bytes.
+func (ss *SnippetStore) Stats() (count int, bytes int64) {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	now := time.Now()
+	for _, snippet := range ss.snippets {
+		if !snippet.IsExpired(now) {
+			count++
+			bytes += int64(len(snippet.Body))
+		}
+	}
+
+	return count, bytes
 }
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+func TestSnippetStoreErrors(t *testing.T) {
+	ss := NewStore().Snippet()
+	ctx := context.Background()
+
+	_, err := ss.Save(ctx, &model.Snippet{Name: "live", ExpiresAt: time.Now().Add(time.Hour)})
+	require.Nil(t, err)
+	_, err = ss.Save(ctx, &model.Snippet{Name: "expired", ExpiresAt: time.Now().Add(-time.Second)})
+	require.Nil(t, err)
+
+	_, err = ss.Save(ctx, &model.Snippet{Name: "live", ExpiresAt: time.Now().Add(time.Hour)})
+	require.NotNil(t, err)
+	assert.True(t, errors.Is(err, model.ErrSnippetNameConflict))
+	assert.Equal(t, http.StatusConflict, err.StatusCode)
+
+	_, err = ss.Get(ctx, "missing")
+	require.NotNil(t, err)
+	assert.True(t, errors.Is(err, model.ErrSnippetNotFound))
+	assert.False(t, errors.Is(err, model.ErrSnippetExpired))
+
+	_, err = ss.Touch(ctx, "expired", time.Minute)
+	require.NotNil(t, err)
+	var kind *model.ErrorKind
+	require.True(t, errors.As(err, &kind))
+	assert.Equal(t, "SnippetExpired", kind.Code)
+	assert.Equal(t, http.StatusGone, err.StatusCode)
+
+	// An expired snippet no longer blocks its name, and is returned as it is replaced.
+	expired, err := ss.Save(ctx, &model.Snippet{Name: "expired", ExpiresAt: time.Now().Add(time.Hour)})
+	assert.Nil(t, err)
+	require.NotNil(t, expired)
+	assert.True(t, expired.IsExpired(time.Now()))
+
+	replaced, err := ss.Save(ctx, &model.Snippet{Name: "new", ExpiresAt: time.Now().Add(time.Hour)})
+	assert.Nil(t, err)
+	assert.Nil(t, replaced)
+}
+
+func TestSnippetStorePatchAndDelete(t *testing.T) {
+	ss := NewStore().Snippet()
+	ctx := context.Background()
+
+	_, err := ss.Save(ctx, &model.Snippet{ID: "id", Name: "live", Body: "body", ExpiresAt: time.Now().Add(time.Minute)})
+	require.Nil(t, err)
+	_, err = ss.Save(ctx, &model.Snippet{Name: "expired", ExpiresAt: time.Now().Add(-time.Second)})
+	require.Nil(t, err)
+
+	patched, err := ss.Patch(ctx, "live", &model.SnippetPatch{Body: model.NewString("changed"), ExpiresIn: model.NewUint64(3600)})
+	require.Nil(t, err)
+	assert.Equal(t, "changed", patched.Body)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), patched.ExpiresAt, time.Second)
+
+	snippet, err := ss.Get(ctx, "live")
+	require.Nil(t, err)
+	assert.Equal(t, "changed", snippet.Body)
+
+	_, err = ss.Patch(ctx, "expired", &model.SnippetPatch{Body: model.NewString("changed")})
+	assert.True(t, errors.Is(err, model.ErrSnippetExpired))
+
+	deleted, err := ss.Delete(ctx, "live")
+	require.Nil(t, err)
+	assert.Equal(t, "id", deleted.ID)
+
+	_, err = ss.Get(ctx, "live")
+	assert.True(t, errors.Is(err, model.ErrSnippetNotFound))
+	_, err = ss.Delete(ctx, "live")
+	assert.True(t, errors.Is(err, model.ErrSnippetNotFound))
+	_, err = ss.Delete(ctx, "expired")
+	assert.True(t, errors.Is(err, model.ErrSnippetExpired))
+}
+
+func TestSnippetStoreGetPage(t *testing.T) {
+	ss := NewStore().Snippet()
+	ctx := context.Background()
+
+	for _, name := range []string{"d", "b", "a", "c"} {
+		_, err := ss.Save(ctx, &model.Snippet{Name: name, ExpiresAt: time.Now().Add(time.Minute)})
+		require.Nil(t, err)
+	}
+	_, err := ss.Save(ctx, &model.Snippet{Name: "expired", ExpiresAt: time.Now().Add(-time.Second)})
+	require.Nil(t, err)
+
+	names := func(page, perPage int) []string {
+		snippets, err := ss.GetPage(ctx, page, perPage)
+		require.Nil(t, err)
+		names := []string{}
+		for _, snippet := range snippets {
+			names = append(names, snippet.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, names(0, 10))
+	assert.Equal(t, []string{"c", "d"}, names(1, 2))
+	assert.Equal(t, []string{"d"}, names(1, 3))
+	assert.Equal(t, []string{}, names(2, 2))
+}
+
+func TestSnippetStoreAttachments(t *testing.T) {
+	ss := NewStore().Snippet()
+	ctx := context.Background()
+
+	_, err := ss.Save(ctx, &model.Snippet{ID: "id", Name: "live", ExpiresAt: time.Now().Add(time.Minute)})
+	require.Nil(t, err)
+
+	before, err := ss.Get(ctx, "live")
+	require.Nil(t, err)
+
+	replaced, err := ss.SaveAttachment(ctx, "live", "id", &model.SnippetAttachment{ID: "first", Name: "notes.txt"})
+	require.Nil(t, err)
+	assert.Nil(t, replaced)
+	_, err = ss.SaveAttachment(ctx, "live", "id", &model.SnippetAttachment{ID: "other", Name: "other.txt"})
+	require.Nil(t, err)
+
+	replaced, err = ss.SaveAttachment(ctx, "live", "id", &model.SnippetAttachment{ID: "second", Name: "notes.txt"})
+	require.Nil(t, err)
+	require.NotNil(t, replaced)
+	assert.Equal(t, "first", replaced.ID)
+
+	snippet, err := ss.Get(ctx, "live")
+	require.Nil(t, err)
+	require.Len(t, snippet.Attachments, 2)
+	assert.Equal(t, "second", snippet.GetAttachment("notes.txt").ID)
+	assert.Empty(t, before.Attachments, "snippets returned earlier are not changed")
+
+	_, err = ss.SaveAttachment(ctx, "live", "recreated", &model.SnippetAttachment{ID: "third", Name: "notes.txt"})
+	assert.True(t, errors.Is(err, model.ErrSnippetNotFound), "the snippet was replaced since the file was stored")
+
+	deleted, err := ss.DeleteAttachment(ctx, "live", "notes.txt")
+	require.Nil(t, err)
+	assert.Equal(t, "second", deleted.GetAttachment("notes.txt").ID)
+
+	snippet, err = ss.Get(ctx, "live")
+	require.Nil(t, err)
+	require.Len(t, snippet.Attachments, 1)
+	assert.Equal(t, "other.txt", snippet.Attachments[0].Name)
+
+	_, err = ss.DeleteAttachment(ctx, "live", "notes.txt")
+	assert.True(t, errors.Is(err, model.ErrNotFound))
+}
@@ -1,8 +1,25 @@
 package store
 
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/topoface/snippet-challenge/model"
+	"github.com/topoface/snippet-challenge/services/metrics"
+	"github.com/topoface/snippet-challenge/services/tracing"
+)
+
 // Store structure
 type Store struct {
 	snippet *SnippetStore
+	audit   *AuditStore
+
+	metrics *metrics.Metrics
+
+	closeLock sync.RWMutex
+	closed    bool
+	writes    sync.WaitGroup
 }
 
 // NewStore : Create new Supplier
@@ -16,4 +33,83 @@ func NewStore() *Store {
 
 func (ss *Store) CreateStores() {
 	ss.snippet = newSnippetStore(ss)
+	ss.audit = newAuditStore(ss)
+}
+
+// Snippet returns the snippet store
+func (ss *Store) Snippet() *SnippetStore {
+	return ss.snippet
+}
+
+// Audit returns the audit store
+func (ss *Store) Audit() *AuditStore {
+	return ss.audit
+}
+
+// SetMetrics enables recording of store method latencies.
+func (ss *Store) SetMetrics(m *metrics.Metrics) {
+	ss.metrics = m
+}
+
+// startMethod starts timing a store method and, when ctx carries a span, tracing it as a child
+// span. It is meant to be deferred as defer ss.startMethod(ctx, name)(&appErr), with appErr
+// being the method's named error result, or nil if the method cannot fail.
+func (ss *Store) startMethod(ctx context.Context, method string) func(appErr **model.AppError) {
+	start := time.Now()
+
+	var span *tracing.Span
+	if tracing.SpanFromContext(ctx) != nil {
+		_, span = tracing.StartSpan(ctx, method)
+	}
+
+	return func(appErr **model.AppError) {
+		success := appErr == nil || *appErr == nil
+		if !success {
+			span.SetError(*appErr)
+		}
+		span.End()
+
+		if ss.metrics != nil {
+			ss.metrics.ObserveStoreMethodDuration(method, success, time.Since(start).Seconds())
+		}
+	}
+}
+
+// beginWrite registers an in-flight write, failing once the store has been closed.
+func (ss *Store) beginWrite(where string) *model.AppError {
+	ss.closeLock.RLock()
+	defer ss.closeLock.RUnlock()
+
+	if ss.closed {
+		return model.ErrUnavailable.NewWithID(where, "store.closed.app_error", nil, "")
+	}
+
+	ss.writes.Add(1)
+	return nil
+}
+
+// endWrite marks an in-flight write registered with beginWrite as finished.
+func (ss *Store) endWrite() {
+	ss.writes.Done()
+}
+
+// Ping checks that the store can accept writes.
+func (ss *Store) Ping() *model.AppError {
+	ss.closeLock.RLock()
+	defer ss.closeLock.RUnlock()
+
+	if ss.closed {
+		return model.ErrUnavailable.NewWithID("Store.Ping", "store.closed.app_error", nil, "")
+	}
+
+	return nil
+}
+
+// Close rejects any new writes and blocks until in-flight writes have been flushed.
+func (ss *Store) Close() {
+	ss.closeLock.Lock()
+	ss.closed = true
+	ss.closeLock.Unlock()
+
+	ss.writes.Wait()
 }
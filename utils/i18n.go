@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+
+	"github.com/topoface/snippet-challenge/model"
+	"github.com/topoface/snippet-challenge/utils/fileutils"
+)
+
+// DEFAULT_LOCALE is the locale used for server messages, and for requests asking for a locale
+// without translations.
+const DEFAULT_LOCALE = "en"
+
+// T translates into the default locale. Until translations are loaded it returns the translation ID.
+var T model.TranslateFunc = translationIDFunc
+
+var (
+	translations = map[string]map[string]*template.Template{}
+	locales      []string
+	matcher      language.Matcher
+)
+
+type translation struct {
+	ID          string `json:"id"`
+	Translation string `json:"translation"`
+}
+
+func translationIDFunc(translationID string, params map[string]interface{}) string {
+	return translationID
+}
+
+// TranslationsPreInit loads the translations from the i18n directory.
+func TranslationsPreInit() error {
+	i18nDirectory, found := fileutils.FindDir("i18n")
+	if !found {
+		return errors.New("unable to find i18n directory")
+	}
+
+	return InitTranslationsWithDir(i18nDirectory)
+}
+
+// InitTranslationsWithDir loads the translations from the locale bundles in the given directory,
+// one JSON file per locale named after it, such as en.json. The default locale must be present.
+func InitTranslationsWithDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return errors.Wrapf(err, "unable to list translations in %s", dir)
+	}
+
+	loaded := map[string]map[string]*template.Template{}
+	for _, file := range files {
+		locale := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		bundle, err := loadTranslationFile(file)
+		if err != nil {
+			return err
+		}
+		loaded[locale] = bundle
+	}
+
+	if _, ok := loaded[DEFAULT_LOCALE]; !ok {
+		return errors.Errorf("missing translations for the default locale %s in %s", DEFAULT_LOCALE, dir)
+	}
+
+	// The default locale goes first so that the matcher falls back to it.
+	loadedLocales := []string{DEFAULT_LOCALE}
+	tags := []language.Tag{language.Make(DEFAULT_LOCALE)}
+	for locale := range loaded {
+		if locale != DEFAULT_LOCALE {
+			loadedLocales = append(loadedLocales, locale)
+			tags = append(tags, language.Make(locale))
+		}
+	}
+
+	translations = loaded
+	locales = loadedLocales
+	matcher = language.NewMatcher(tags)
+	T = GetTranslationFunc(DEFAULT_LOCALE)
+
+	return nil
+}
+
+func loadTranslationFile(file string) (map[string]*template.Template, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read translations from %s", file)
+	}
+
+	var entries []translation
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse translations from %s", file)
+	}
+
+	bundle := make(map[string]*template.Template, len(entries))
+	for _, entry := range entries {
+		tmpl, err := template.New(entry.ID).Parse(entry.Translation)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid translation of %s in %s", entry.ID, file)
+		}
+		bundle[entry.ID] = tmpl
+	}
+
+	return bundle, nil
+}
+
+// GetTranslationFunc returns a func translating into the given locale. Messages missing from the
+// locale fall back to the default locale, and then to the translation ID itself.
+func GetTranslationFunc(locale string) model.TranslateFunc {
+	bundle := translations[locale]
+	fallback := translations[DEFAULT_LOCALE]
+
+	return func(translationID string, params map[string]interface{}) string {
+		tmpl, ok := bundle[translationID]
+		if !ok {
+			if tmpl, ok = fallback[translationID]; !ok {
+				return translationID
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, params); err != nil {
+			return translationID
+		}
+		return buf.String()
+	}
+}
+
+// GetTranslationsAndLocale returns the translations for the best locale requested by the
+// Accept-Language header, and the name of that locale.
+func GetTranslationsAndLocale(r *http.Request) (model.TranslateFunc, string) {
+	locale := DEFAULT_LOCALE
+	if matcher != nil {
+		if tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language")); err == nil && len(tags) > 0 {
+			if _, index, confidence := matcher.Match(tags...); confidence != language.No {
+				locale = locales[index]
+			}
+		}
+	}
+
+	return GetTranslationFunc(locale), locale
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/model"
+)
+
+func TestTranslations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "i18n")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.Error(t, InitTranslationsWithDir(dir), "the default locale is required")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "en.json"), []byte(`[
+		{"id": "api.context.invalid_url_param", "translation": "Invalid or missing {{.Name}} parameter in request URL."},
+		{"id": "model.app_error.not_found", "translation": "Not found."}
+	]`), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "fr.json"), []byte(`[
+		{"id": "api.context.invalid_url_param", "translation": "Paramètre {{.Name}} invalide ou manquant dans l'URL."}
+	]`), 0600))
+	require.NoError(t, InitTranslationsWithDir(dir))
+	defer func() {
+		T = translationIDFunc
+		translations = map[string]map[string]*template.Template{}
+		locales = nil
+		matcher = nil
+	}()
+
+	t.Run("locale from Accept-Language", func(t *testing.T) {
+		for header, expected := range map[string]string{
+			"":                          "en",
+			"fr":                        "fr",
+			"fr-CA,fr;q=0.9":            "fr",
+			"de,fr;q=0.5":               "fr",
+			"en;q=0.9,fr":               "fr",
+			"de":                        "en",
+			"not a valid header;q=what": "en",
+		} {
+			r, _ := http.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Language", header)
+			_, locale := GetTranslationsAndLocale(r)
+			assert.Equal(t, expected, locale, "Accept-Language: %s", header)
+		}
+	})
+
+	t.Run("params and fallback", func(t *testing.T) {
+		fr := GetTranslationFunc("fr")
+		params := map[string]interface{}{"Name": "page"}
+		assert.Equal(t, "Paramètre page invalide ou manquant dans l'URL.", fr("api.context.invalid_url_param", params))
+		assert.Equal(t, "Not found.", fr("model.app_error.not_found", nil), "falls back to the default locale")
+		assert.Equal(t, "unknown.id", fr("unknown.id", nil), "falls back to the translation ID")
+		assert.Equal(t, "Invalid or missing page parameter in request URL.", T("api.context.invalid_url_param", params))
+	})
+
+	t.Run("app error", func(t *testing.T) {
+		appErr := model.NewInvalidUrlParamError("page")
+		assert.Equal(t, "Invalid or missing page parameter in request URL.", appErr.SystemMessage(T))
+
+		appErr.Translate(GetTranslationFunc("fr"))
+		assert.Equal(t, "Paramètre page invalide ou manquant dans l'URL.", appErr.Message)
+		assert.Contains(t, appErr.ToJSON(), `"detail":"Paramètre page invalide ou manquant dans l'URL."`)
+	})
+}
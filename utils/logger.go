@@ -10,24 +10,74 @@ import (
 )
 
 const (
-	LOG_ROTATE_SIZE = 10000
 	LOG_FILENAME    = "erniepjt.log"
+	AUDIT_FILENAME  = "audit.log"
+	TRACES_FILENAME = "traces.jsonl"
 )
 
 type fileLocationFunc func(string) string
 
 func MloggerConfigFromLoggerConfig(s *model.LogSettings, getFileFunc fileLocationFunc) *mlog.LoggerConfiguration {
 	return &mlog.LoggerConfiguration{
-		EnableConsole: *s.EnableConsole,
-		ConsoleJSON:   *s.ConsoleJSON,
-		ConsoleLevel:  strings.ToLower(*s.ConsoleLevel),
-		EnableFile:    *s.EnableFile,
-		FileJSON:      *s.FileJSON,
-		FileLevel:     strings.ToLower(*s.FileLevel),
-		FileLocation:  getFileFunc(*s.FileLocation),
+		EnableConsole:  *s.EnableConsole,
+		ConsoleJSON:    *s.ConsoleJSON,
+		ConsoleLevel:   strings.ToLower(*s.ConsoleLevel),
+		EnableFile:     *s.EnableFile,
+		FileJSON:       *s.FileJSON,
+		FileLevel:      strings.ToLower(*s.FileLevel),
+		FileLocation:   getFileFunc(*s.FileLocation),
+		FileMaxSize:    *s.FileMaxSizeMB,
+		FileMaxBackups: *s.FileMaxBackups,
+		FileMaxAge:     *s.FileMaxAgeDays,
+		FileCompress:   *s.FileCompress,
+		Sinks:          mloggerSinkConfigsFromLogSinkSettings(s.Sinks),
+		Sampling:       mloggerSamplingConfigsFromLogSamplingSettings(s.Sampling),
+		RedactedFields: s.RedactedFields,
 	}
 }
 
+// MloggerConfigFromAuditConfig configures the logger of the audit log file, which holds one JSON
+// line per audit record and nothing else.
+func MloggerConfigFromAuditConfig(s *model.AuditSettings, getFileFunc fileLocationFunc) *mlog.LoggerConfiguration {
+	return &mlog.LoggerConfiguration{
+		EnableFile:     *s.Enable && *s.FileEnabled,
+		FileJSON:       true,
+		FileLevel:      mlog.LevelInfo,
+		FileLocation:   getFileFunc(*s.FileLocation),
+		FileMaxSize:    *s.FileMaxSizeMB,
+		FileMaxBackups: *s.FileMaxBackups,
+		FileMaxAge:     *s.FileMaxAgeDays,
+		FileCompress:   *s.FileCompress,
+	}
+}
+
+func mloggerSamplingConfigsFromLogSamplingSettings(sampling []model.LogSamplingSettings) []mlog.SamplingConfiguration {
+	configs := make([]mlog.SamplingConfiguration, 0, len(sampling))
+	for _, s := range sampling {
+		configs = append(configs, mlog.SamplingConfiguration{
+			Level:      strings.ToLower(*s.Level),
+			Initial:    *s.Initial,
+			Thereafter: *s.Thereafter,
+		})
+	}
+	return configs
+}
+
+func mloggerSinkConfigsFromLogSinkSettings(sinks []model.LogSinkSettings) []mlog.SinkConfiguration {
+	configs := make([]mlog.SinkConfiguration, 0, len(sinks))
+	for _, s := range sinks {
+		configs = append(configs, mlog.SinkConfiguration{
+			Type:       *s.Type,
+			Address:    *s.Address,
+			Level:      strings.ToLower(*s.Level),
+			JSON:       *s.JSON,
+			Tag:        *s.Tag,
+			BufferSize: *s.BufferSize,
+		})
+	}
+	return configs
+}
+
 func GetLogFileLocation(fileLocation string) string {
 	if fileLocation == "" {
 		fileLocation, _ = fileutils.FindDir("logs")
@@ -36,6 +86,22 @@ func GetLogFileLocation(fileLocation string) string {
 	return filepath.Join(fileLocation, LOG_FILENAME)
 }
 
+func GetAuditFileLocation(fileLocation string) string {
+	if fileLocation == "" {
+		fileLocation, _ = fileutils.FindDir("logs")
+	}
+
+	return filepath.Join(fileLocation, AUDIT_FILENAME)
+}
+
+func GetTracesFileLocation(fileLocation string) string {
+	if fileLocation == "" {
+		fileLocation, _ = fileutils.FindDir("logs")
+	}
+
+	return filepath.Join(fileLocation, TRACES_FILENAME)
+}
+
 // DON'T USE THIS Modify the level on the app logger
 func DisableDebugLogForTest() {
 	mlog.GloballyDisableDebugLogForTest()
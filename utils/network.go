@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// GetIPAddress returns the client IP address. Since any client can set the proxy headers
+// X-Forwarded-For and X-Real-IP, they are only honoured when the request comes from one of the
+// trusted proxies, given as IP addresses or CIDR ranges.
+func GetIPAddress(r *http.Request, trustedProxies []string) string {
+	address := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		address = host
+	}
+
+	if !isTrustedProxy(address, trustedProxies) {
+		return address
+	}
+
+	if forwardedFor := r.Header.Values("X-Forwarded-For"); len(forwardedFor) > 0 {
+		// Each proxy appends the address it got the request from, so the client is the right-most
+		// address that is not a trusted proxy. Anything left of it may have been forged.
+		hops := strings.Split(strings.Join(forwardedFor, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+
+			address = hop
+			if !isTrustedProxy(hop, trustedProxies) {
+				break
+			}
+		}
+
+		return address
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+
+	return address
+}
+
+// isTrustedProxy reports whether the address matches one of the trusted IP addresses or CIDR ranges.
+func isTrustedProxy(address string, trustedProxies []string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+
+	for _, proxy := range trustedProxies {
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if proxyIP := net.ParseIP(proxy); proxyIP != nil && proxyIP.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetIPAddress(t *testing.T) {
+	trustedProxies := []string{"10.0.0.0/8", "192.168.1.1", "fd00::/8"}
+
+	for name, tc := range map[string]struct {
+		RemoteAddr     string
+		ForwardedFor   []string
+		RealIP         string
+		TrustedProxies []string
+		Expected       string
+	}{
+		"direct client":                  {"203.0.113.5:1234", nil, "", trustedProxies, "203.0.113.5"},
+		"untrusted peer forging headers": {"203.0.113.5:1234", []string{"198.51.100.1"}, "198.51.100.2", trustedProxies, "203.0.113.5"},
+		"no trusted proxies":             {"10.0.0.1:1234", []string{"198.51.100.1"}, "", nil, "10.0.0.1"},
+		"proxy in range":                 {"10.0.0.1:1234", []string{"198.51.100.1"}, "", trustedProxies, "198.51.100.1"},
+		"proxy by address":               {"192.168.1.1:1234", []string{"198.51.100.1"}, "", trustedProxies, "198.51.100.1"},
+		"ipv6 proxy":                     {"[fd00::1]:1234", []string{"2001:db8::1"}, "", trustedProxies, "2001:db8::1"},
+		"client prepending a forged hop": {"10.0.0.1:1234", []string{"1.2.3.4, 198.51.100.1"}, "", trustedProxies, "198.51.100.1"},
+		"chain of trusted proxies":       {"10.0.0.1:1234", []string{"198.51.100.1, 10.0.0.2"}, "", trustedProxies, "198.51.100.1"},
+		"repeated headers":               {"10.0.0.1:1234", []string{"1.2.3.4", "198.51.100.1, 10.0.0.2"}, "", trustedProxies, "198.51.100.1"},
+		"only trusted hops":              {"10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, "", trustedProxies, "10.0.0.3"},
+		"malformed hop":                  {"10.0.0.1:1234", []string{"not-an-ip, 10.0.0.2"}, "", trustedProxies, "10.0.0.2"},
+		"real ip from proxy":             {"10.0.0.1:1234", nil, "198.51.100.1", trustedProxies, "198.51.100.1"},
+		"malformed real ip":              {"10.0.0.1:1234", nil, "not-an-ip", trustedProxies, "10.0.0.1"},
+		"remote address without port":    {"203.0.113.5", nil, "", trustedProxies, "203.0.113.5"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tc.RemoteAddr
+			for _, forwardedFor := range tc.ForwardedFor {
+				r.Header.Add("X-Forwarded-For", forwardedFor)
+			}
+			if tc.RealIP != "" {
+				r.Header.Set("X-Real-IP", tc.RealIP)
+			}
+
+			assert.Equal(t, tc.Expected, GetIPAddress(r, tc.TrustedProxies))
+		})
+	}
+}
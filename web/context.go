@@ -6,6 +6,7 @@ import (
 	"github.com/topoface/snippet-challenge/app"
 	"github.com/topoface/snippet-challenge/mlog"
 	"github.com/topoface/snippet-challenge/model"
+	"github.com/topoface/snippet-challenge/utils"
 )
 
 // Context structure
@@ -13,26 +14,42 @@ type Context struct {
 	App           app.Iface
 	Log           *mlog.Logger
 	Err           *model.AppError
+	RequestID     string
+	IPAddress     string
+	Actor         string
+	T             model.TranslateFunc
+	Locale        string
 	siteURLHeader string
 }
 
-func (c *Context) LogError(err *model.AppError) {
-	// Filter out 404s, endless reconnects and browser compatibility errors
-	var isDebug bool
-	isDebug = false
-	if err.StatusCode == http.StatusNotFound {
-		isDebug = true
+// MakeAuditRecord starts an audit record of the event for the request. The record is failed
+// until marked successful, and should be saved with LogAuditRec once the request is handled.
+func (c *Context) MakeAuditRecord(event string) *model.Audit {
+	return &model.Audit{
+		Event:     event,
+		Actor:     c.Actor,
+		ClientIP:  c.IPAddress,
+		RequestID: c.RequestID,
+		Status:    model.AUDIT_STATUS_FAIL,
 	}
-	if detail, ok := c.Err.Errors[0]["detail"]; ok {
-		if errArr, ok := detail.([]*model.Error); ok && errArr[0].ID == "web.check_browser_compatibility.app_error" {
-			isDebug = true
-		}
+}
+
+// LogAuditRec saves the audit record, marking it failed with c.Err if the request failed.
+func (c *Context) LogAuditRec(rec *model.Audit) {
+	if c.Err != nil {
+		rec.Fail(c.Err)
 	}
-	if isDebug {
+
+	c.App.LogAuditRec(rec)
+}
+
+func (c *Context) LogError(err *model.AppError) {
+	// Filter out 404s and 410s, which are expected as snippets expire
+	if err.StatusCode == http.StatusNotFound || err.StatusCode == http.StatusGone {
 		c.LogDebug(err)
 	} else {
 		c.Log.Error(
-			err.SystemMessage(),
+			err.SystemMessage(utils.T),
 			mlog.String("err_where", err.Where),
 			mlog.Int("http_code", err.StatusCode),
 			mlog.String("err_details", err.DetailedError),
@@ -46,7 +63,7 @@ func (c *Context) LogInfo(err *model.AppError) {
 		c.LogDebug(err)
 	} else {
 		c.Log.Info(
-			err.SystemMessage(),
+			err.SystemMessage(utils.T),
 			mlog.String("err_where", err.Where),
 			mlog.Int("http_code", err.StatusCode),
 			mlog.String("err_details", err.DetailedError),
@@ -56,7 +73,7 @@ func (c *Context) LogInfo(err *model.AppError) {
 
 func (c *Context) LogDebug(err *model.AppError) {
 	c.Log.Debug(
-		err.SystemMessage(),
+		err.SystemMessage(utils.T),
 		mlog.String("err_where", err.Where),
 		mlog.Int("http_code", err.StatusCode),
 		mlog.String("err_details", err.DetailedError),
@@ -1,19 +1,30 @@
 package web
 
 import (
+	"crypto/subtle"
+	"fmt"
+	"mime"
 	"net/http"
 	"reflect"
 	"runtime"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/topoface/snippet-challenge/app"
 	"github.com/topoface/snippet-challenge/mlog"
 	"github.com/topoface/snippet-challenge/model"
+	"github.com/topoface/snippet-challenge/services/tracing"
+	"github.com/topoface/snippet-challenge/utils"
 )
 
+const maxRequestIDLength = 128
+
 // GetHandlerName : get handler name from handler func
 func GetHandlerName(h func(*Context, http.ResponseWriter, *http.Request)) string {
-	handlerName := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+	// Method values are named after the method with a -fm suffix.
+	handlerName := strings.TrimSuffix(runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name(), "-fm")
 	pos := strings.LastIndex(handlerName, ".")
 	if pos != -1 && len(handlerName) > pos {
 		handlerName = handlerName[pos+1:]
@@ -27,19 +38,104 @@ type Handler struct {
 	HandleFunc          func(*Context, http.ResponseWriter, *http.Request)
 	HandlerName         string
 	RequireSession      bool
+	RequireAdmin        bool
+}
+
+// isValidRequestID reports whether a client supplied request ID is safe to log and echo back.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+
+	return true
 }
 
 func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	mlog.Debug("Received HTTP request", mlog.String("method", r.Method), mlog.String("url", r.URL.Path))
+	start := time.Now()
+	ww := newWrappedWriter(w)
+	w = ww
 
 	c := &Context{}
+
+	// Registered first, so that a panic anywhere while serving the request, setting up the context
+	// included, is recovered. The request is ended afterwards, to be logged with the final status.
+	var endRequest func()
+	defer func() {
+		if rec := recover(); rec != nil {
+			// Let net/http abort the response the way the handler asked it to.
+			if rec == http.ErrAbortHandler {
+				if endRequest != nil {
+					endRequest()
+				}
+				panic(rec)
+			}
+			h.recoverPanic(c, ww, r, rec)
+		}
+
+		if endRequest != nil {
+			endRequest()
+		}
+	}()
+
 	c.App = app.New(
 		h.GetGlobalAppOptions()...,
 	)
 
+	c.RequestID = r.Header.Get(model.HEADER_REQUEST_ID)
+	if !isValidRequestID(c.RequestID) {
+		c.RequestID = model.NewID()
+	}
+
+	c.T, c.Locale = utils.GetTranslationsAndLocale(r)
+	c.IPAddress = utils.GetIPAddress(r, c.App.Config().ServiceSettings.TrustedProxies)
+	c.Actor = model.AUDIT_ACTOR_ANONYMOUS
+
 	c.App.SetPath(r.URL.Path)
 	c.Log = c.App.Log()
 
+	ctx := tracing.ContextWithTraceParent(r.Context(), r.Header.Get(tracing.HeaderTraceParent))
+	ctx, span := tracing.StartSpan(ctx, h.HandlerName)
+	span.SetAttribute("http.method", r.Method)
+	span.SetAttribute("http.target", r.URL.Path)
+	span.SetAttribute("request_id", c.RequestID)
+	c.App.SetContext(ctx)
+
+	if c.App.Metrics() != nil {
+		c.App.Metrics().IncrementHTTPRequestsInFlight()
+	}
+
+	endRequest = func() {
+		elapsed := time.Since(start)
+
+		span.SetAttribute("http.status_code", ww.StatusCode())
+		if ww.StatusCode() >= http.StatusInternalServerError {
+			span.SetError(fmt.Errorf("%s", http.StatusText(ww.StatusCode())))
+		} else {
+			span.SetError(nil)
+		}
+		span.End()
+		if c.App.Metrics() != nil {
+			c.App.Metrics().DecrementHTTPRequestsInFlight()
+			c.App.Metrics().ObserveHTTPRequest(h.HandlerName, r.Method, ww.StatusCode(), elapsed.Seconds())
+		}
+
+		c.Log.Info("HTTP request",
+			mlog.Int("status", ww.StatusCode()),
+			mlog.Int("bytes", ww.bytes),
+			mlog.Duration("latency", elapsed),
+			mlog.String("client_ip", c.IPAddress),
+			mlog.String("handler", h.HandlerName),
+		)
+	}
+
+	w.Header().Set(model.HEADER_REQUEST_ID, c.RequestID)
+
 	// All api response bodies will be JSON formatted by default
 	w.Header().Set("Content-Type", "application/json")
 
@@ -51,7 +147,22 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	c.Log = c.App.Log().With(
 		mlog.String("path", c.App.Path()),
 		mlog.String("method", r.Method),
+		mlog.String("request_id", c.RequestID),
 	)
+	if span != nil {
+		c.Log = c.Log.With(mlog.String("trace_id", span.TraceID()))
+	}
+
+	if h.RequireAdmin {
+		c.Err = checkAdminToken(c, r)
+		if c.Err == nil {
+			c.Actor = model.AUDIT_ACTOR_ADMIN
+		} else {
+			auditRec := c.MakeAuditRecord(h.HandlerName)
+			auditRec.Target = r.URL.Path
+			c.LogAuditRec(auditRec)
+		}
+	}
 
 	// process requests
 	if c.Err == nil {
@@ -60,28 +171,102 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Handle errors that have occurred
 	if c.Err != nil {
-		var isInfo bool
-		isInfo = false
-		if detail, ok := c.Err.Errors[0]["detail"]; ok {
-			if errArr, ok := detail.([]*model.Error); ok && errArr[0].ID == "api.context.session_expired" {
-				isInfo = true
-			}
-		}
-		if isInfo {
-			c.LogInfo(c.Err)
-		} else {
-			c.LogError(c.Err)
-		}
+		h.writeError(c, w, r)
+	}
+}
 
-		c.Err.Where = r.URL.Path
+// checkAdminToken verifies that the request carries the configured ServiceSettings.AdminAccessToken
+// as a bearer token. Admin endpoints are disabled while no token is configured.
+func checkAdminToken(c *Context, r *http.Request) *model.AppError {
+	adminToken := *c.App.Config().ServiceSettings.AdminAccessToken
+	if adminToken == "" {
+		return model.PermissionDeniedError("checkAdminToken", "admin access token is not configured")
+	}
 
-		// Block out detailed error when not in developer mode
-		if !*c.App.Config().ServiceSettings.EnableDeveloper {
-			c.Err.DetailedError = ""
-		}
+	authHeader := r.Header.Get(model.HEADER_AUTH)
+	prefix := model.HEADER_BEARER + " "
+	if len(authHeader) <= len(prefix) || !strings.EqualFold(authHeader[:len(prefix)], prefix) {
+		return model.NotAuthenticatedError("checkAdminToken", "missing bearer token")
+	}
+
+	token := strings.TrimSpace(authHeader[len(prefix):])
+	if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+		return model.NotAuthenticatedError("checkAdminToken", "invalid admin access token")
+	}
+
+	return nil
+}
+
+// recoverPanic logs a panic raised while serving the request and converts it into a 500 response.
+func (h Handler) recoverPanic(c *Context, ww *responseWriterWrapper, r *http.Request, rec interface{}) {
+	stack := string(debug.Stack())
+	logCritical := mlog.Critical
+	if c.Log != nil {
+		logCritical = c.Log.Critical
+	}
+	logCritical("Recovered from panic while handling request",
+		mlog.String("handler", h.HandlerName),
+		mlog.Any("panic", rec),
+		mlog.String("stack", stack),
+	)
+
+	// Nothing sensible can be sent once the handler has started writing the response.
+	if ww.statusCode != 0 {
+		return
+	}
+
+	// A panic while setting up the context leaves nothing to translate and format the error with.
+	if c.App == nil || c.T == nil {
+		ww.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	c.Err = model.NewAppError(h.HandlerName, "api.context.panic.app_error", nil, fmt.Sprintf("%v\n%s", rec, stack), http.StatusInternalServerError)
+	h.writeError(c, ww, r)
+}
+
+// writeError logs c.Err and writes it to the response.
+func (h Handler) writeError(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.LogError(c.Err)
+
+	c.Err.Where = r.URL.Path
+	c.Err.RequestID = c.RequestID
 
+	// Block out detailed error when not in developer mode
+	if !*c.App.Config().ServiceSettings.EnableDeveloper {
+		c.Err.DetailedError = ""
+	}
+
+	c.Err.Translate(c.T)
+	w.Header().Set("Content-Language", c.Locale)
+
+	if *c.App.Config().ServiceSettings.ErrorResponseFormat == model.ERROR_RESPONSE_FORMAT_PROBLEM || acceptsProblemJSON(r) {
+		w.Header().Set("Content-Type", model.PROBLEM_CONTENT_TYPE)
 		w.WriteHeader(c.Err.StatusCode)
-		w.Write([]byte(c.Err.ToJSON()))
+		w.Write([]byte(c.Err.ToProblemJSON()))
 		return
 	}
+
+	w.WriteHeader(c.Err.StatusCode)
+	w.Write([]byte(c.Err.ToJSON()))
+}
+
+// acceptsProblemJSON reports whether the request's Accept header asks for RFC 7807 problem details.
+func acceptsProblemJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(mediaRange)
+			if err != nil || mediaType != model.PROBLEM_CONTENT_TYPE {
+				continue
+			}
+
+			if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+				continue
+			}
+
+			return true
+		}
+	}
+
+	return false
 }
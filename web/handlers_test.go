@@ -0,0 +1,172 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/topoface/snippet-challenge/app"
+	"github.com/topoface/snippet-challenge/config"
+	"github.com/topoface/snippet-challenge/mlog"
+	"github.com/topoface/snippet-challenge/model"
+	"github.com/topoface/snippet-challenge/utils"
+)
+
+func TestAcceptsProblemJSON(t *testing.T) {
+	for name, tc := range map[string]struct {
+		Accept   []string
+		Expected bool
+	}{
+		"no accept header":           {nil, false},
+		"json":                       {[]string{"application/json"}, false},
+		"any":                        {[]string{"*/*"}, false},
+		"problem json":               {[]string{"application/problem+json"}, true},
+		"with charset":               {[]string{"application/problem+json; charset=utf-8"}, true},
+		"in a list":                  {[]string{"application/json, application/problem+json;q=0.9"}, true},
+		"in a later header":          {[]string{"application/json", "application/problem+json"}, true},
+		"refused with q=0":           {[]string{"application/problem+json;q=0"}, false},
+		"refused with q=0.0":         {[]string{"application/json, application/problem+json; q=0.0"}, false},
+		"refused then accepted":      {[]string{"application/problem+json;q=0", "application/problem+json;q=0.5"}, true},
+		"malformed range skipped":    {[]string{"application/;;, application/problem+json"}, true},
+		"different type":             {[]string{"application/problem+xml"}, false},
+		"invalid q is not a refusal": {[]string{"application/problem+json;q=x"}, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, accept := range tc.Accept {
+				r.Header.Add("Accept", accept)
+			}
+
+			assert.Equal(t, tc.Expected, acceptsProblemJSON(r))
+		})
+	}
+}
+
+// newTestContext returns the context of a request to a server with the default config, modified by
+// updateConfig.
+func newTestContext(t *testing.T, r *http.Request, updateConfig func(*model.Config)) *Context {
+	cfg := &model.Config{}
+	cfg.SetDefaults()
+	*cfg.LogSettings.EnableConsole = false
+	*cfg.LogSettings.EnableFile = false
+	*cfg.AuditSettings.FileEnabled = false
+	updateConfig(cfg)
+
+	configStore, err := config.NewMemoryStoreWithOptions(&config.MemoryStoreOptions{InitialConfig: cfg})
+	require.NoError(t, err)
+
+	server, err := app.NewServer(app.ConfigStore(configStore), app.SetLogger(mlog.NewLogger(&mlog.LoggerConfiguration{})))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		server.Shutdown()
+	})
+
+	c := &Context{
+		App:       server.FakeApp(),
+		Log:       server.Log,
+		RequestID: "request-id",
+	}
+	c.T, c.Locale = utils.GetTranslationsAndLocale(r)
+
+	return c
+}
+
+func TestWriteError(t *testing.T) {
+	for name, tc := range map[string]struct {
+		Format          string
+		Accept          string
+		EnableDeveloper bool
+		Problem         bool
+	}{
+		"json":                        {model.ERROR_RESPONSE_FORMAT_JSON, "", false, false},
+		"json requested as problem":   {model.ERROR_RESPONSE_FORMAT_JSON, model.PROBLEM_CONTENT_TYPE, false, true},
+		"json with problem refused":   {model.ERROR_RESPONSE_FORMAT_JSON, model.PROBLEM_CONTENT_TYPE + ";q=0", false, false},
+		"problem":                     {model.ERROR_RESPONSE_FORMAT_PROBLEM, "", false, true},
+		"problem even if json wanted": {model.ERROR_RESPONSE_FORMAT_PROBLEM, "application/json", false, true},
+		"json in developer mode":      {model.ERROR_RESPONSE_FORMAT_JSON, "", true, false},
+		"problem in developer mode":   {model.ERROR_RESPONSE_FORMAT_PROBLEM, "", true, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/snippets/recipe", nil)
+			if tc.Accept != "" {
+				r.Header.Set("Accept", tc.Accept)
+			}
+
+			c := newTestContext(t, r, func(cfg *model.Config) {
+				*cfg.ServiceSettings.ErrorResponseFormat = tc.Format
+				*cfg.ServiceSettings.EnableDeveloper = tc.EnableDeveloper
+			})
+			c.Err = model.ErrSnippetNotFound.New("SnippetStore.Get", nil, "name=recipe")
+
+			w := httptest.NewRecorder()
+			Handler{HandlerName: "getSnippet"}.writeError(c, w, r)
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.Equal(t, "en", w.Header().Get("Content-Language"))
+
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+
+			if tc.Problem {
+				assert.Equal(t, model.PROBLEM_CONTENT_TYPE, w.Header().Get("Content-Type"))
+				assert.Equal(t, model.PROBLEM_TYPE_PREFIX+"SnippetNotFound", body["type"])
+				assert.Equal(t, "Not Found", body["title"])
+				assert.EqualValues(t, http.StatusNotFound, body["status"])
+				assert.Equal(t, "/api/v1/snippets/recipe", body["instance"])
+				assert.NotEmpty(t, body["detail"])
+			} else {
+				assert.Empty(t, w.Header().Get("Content-Type"), "left to the handler's default")
+				assert.Equal(t, "SnippetNotFound", body["error"])
+				assert.NotEmpty(t, body["data"])
+			}
+			assert.Equal(t, "request-id", body["request_id"])
+
+			if tc.EnableDeveloper {
+				assert.Equal(t, "name=recipe", body["detailed_error"])
+			} else {
+				assert.NotContains(t, body, "detailed_error")
+			}
+		})
+	}
+}
+
+func TestServeHTTPRecoversPanic(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/snippets/recipe", nil)
+	c := newTestContext(t, r, func(cfg *model.Config) {})
+
+	t.Run("in handler", func(t *testing.T) {
+		handler := Handler{
+			GetGlobalAppOptions: c.App.Srv().AppOptions,
+			HandleFunc: func(*Context, http.ResponseWriter, *http.Request) {
+				panic("handler")
+			},
+			HandlerName: "panicking",
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), "An unexpected error occurred")
+	})
+
+	t.Run("while setting up the context", func(t *testing.T) {
+		handler := Handler{
+			GetGlobalAppOptions: func() []app.AppOption {
+				panic("setup")
+			},
+			HandleFunc:  func(*Context, http.ResponseWriter, *http.Request) {},
+			HandlerName: "panicking",
+		}
+
+		w := httptest.NewRecorder()
+		require.NotPanics(t, func() {
+			handler.ServeHTTP(w, r)
+		})
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
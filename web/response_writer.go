@@ -0,0 +1,60 @@
+package web
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// responseWriterWrapper records the status code and number of bytes written to the response.
+type responseWriterWrapper struct {
+	http.ResponseWriter
+	statusCode int
+	bytes      int
+}
+
+func newWrappedWriter(original http.ResponseWriter) *responseWriterWrapper {
+	return &responseWriterWrapper{
+		ResponseWriter: original,
+	}
+}
+
+func (rw *responseWriterWrapper) WriteHeader(statusCode int) {
+	if rw.statusCode == 0 {
+		rw.statusCode = statusCode
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *responseWriterWrapper) Write(data []byte) (int, error) {
+	if rw.statusCode == 0 {
+		rw.statusCode = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(data)
+	rw.bytes += n
+	return n, err
+}
+
+// StatusCode returns the status code sent to the client, defaulting to 200 when none was set.
+func (rw *responseWriterWrapper) StatusCode() int {
+	if rw.statusCode == 0 {
+		return http.StatusOK
+	}
+	return rw.statusCode
+}
+
+func (rw *responseWriterWrapper) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (rw *responseWriterWrapper) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacker interface not supported by the wrapped ResponseWriter")
+	}
+	return hijacker.Hijack()
+}